@@ -2,6 +2,7 @@ package alacarte_test
 
 import (
 	"database/sql"
+	"path/filepath"
 	"testing"
 
 	"github.com/Masterminds/squirrel"
@@ -22,6 +23,12 @@ type Book struct {
 	AuthorID uint64
 	Comments []Comment
 	Author   *Author
+	Genres   []Genre
+}
+
+type Genre struct {
+	ID   uint64
+	Name string
 }
 
 type Comment struct {
@@ -43,6 +50,20 @@ func setupDB(t testing.TB) (*sql.DB, squirrel.StatementBuilderType) {
 	return db, sq
 }
 
+// setupFileDB creates a database on disk, which unlike the in-memory database can be used by multiple connections.
+func setupFileDB(t testing.TB) (*sql.DB, squirrel.StatementBuilderType) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(migrate)
+	require.NoError(t, err)
+
+	sq := squirrel.StatementBuilder.RunWith(db)
+
+	return db, sq
+}
+
 const migrate = `
 	create table authors (
 		id integer not null,
@@ -59,4 +80,12 @@ const migrate = `
 		name text not null,
 		book_id integer
 	);
+	create table genres (
+		id integer not null,
+		name text not null
+	);
+	create table book_genres (
+		book_id integer not null,
+		genre_id integer not null
+	);
 	`
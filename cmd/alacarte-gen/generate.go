@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"pollex.nl/alacarte/internal/naming"
+)
+
+const directive = "//alacarte:table "
+
+type model struct {
+	Name      string
+	Table     string
+	File      string
+	Fields    []field
+	Relations []relation
+}
+
+type field struct {
+	GoName     string
+	Column     string
+	Type       string
+	Sortable   bool
+	Filterable bool
+	Hidden     bool
+}
+
+type relation struct {
+	GoName     string
+	Name       string
+	Kind       string
+	Target     string
+	Pointer    bool
+	Key        string
+	ForeignKey string
+}
+
+// generate parses the package in dir and returns the generated source for the annotated structs of file.
+func generate(dir, file string) ([]byte, error) {
+	pkg, models, err := parseModels(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var generated []*model
+	for _, model := range models {
+		if model.File == file {
+			generated = append(generated, model)
+		}
+	}
+	sort.Slice(generated, func(i, j int) bool { return generated[i].Name < generated[j].Name })
+
+	if len(generated) == 0 {
+		return nil, fmt.Errorf("no structs with %q in %s", strings.TrimSpace(directive), file)
+	}
+
+	data := templateData{Package: pkg, File: file}
+	for _, model := range generated {
+		out, err := buildModel(model, models)
+		if err != nil {
+			return nil, err
+		}
+		data.Models = append(data.Models, out)
+	}
+
+	var buffer bytes.Buffer
+	if err := outputTemplate.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buffer.String())
+	}
+
+	return source, nil
+}
+
+// parseModels parses the annotated structs of all go files in dir, so relations can refer to structs in other files.
+func parseModels(dir string) (string, map[string]*model, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		pkg    string
+		fset   = token.NewFileSet()
+		models = map[string]*model{}
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") ||
+			strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, "_alacarte.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		pkg = file.Name.Name
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				table := tableDirective(typeSpec.Doc)
+				if table == "" && len(genDecl.Specs) == 1 {
+					table = tableDirective(genDecl.Doc)
+				}
+				if table == "" {
+					continue
+				}
+
+				model, err := parseStruct(typeSpec.Name.Name, table, structType)
+				if err != nil {
+					return "", nil, err
+				}
+				model.File = name
+				models[model.Name] = model
+			}
+		}
+	}
+
+	return pkg, models, nil
+}
+
+func tableDirective(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, comment := range doc.List {
+		if table, ok := strings.CutPrefix(comment.Text, directive); ok {
+			return strings.TrimSpace(table)
+		}
+	}
+	return ""
+}
+
+func parseStruct(name, table string, structType *ast.StructType) (*model, error) {
+	result := &model{Name: name, Table: table}
+
+	for _, astField := range structType.Fields.List {
+		if astField.Tag == nil || len(astField.Names) != 1 {
+			continue
+		}
+		goName := astField.Names[0].Name
+		tagValue, err := strconv.Unquote(astField.Tag.Value)
+		if err != nil {
+			return nil, err
+		}
+		tag := reflect.StructTag(tagValue)
+
+		if db, ok := tag.Lookup("db"); ok && db != "-" {
+			column, options, _ := strings.Cut(db, ",")
+			if column == "" {
+				column = naming.SnakeCase(goName)
+			}
+
+			f := field{GoName: goName, Column: column, Type: typeString(astField.Type), Sortable: true, Filterable: true}
+			if options != "" {
+				var sortable, filterable, restricted bool
+				for _, option := range strings.Split(options, ",") {
+					switch strings.TrimSpace(option) {
+					case "sortable":
+						sortable, restricted = true, true
+					case "filterable":
+						filterable, restricted = true, true
+					case "hidden":
+						f.Hidden = true
+					default:
+						return nil, fmt.Errorf("%s.%s: unknown db option %q", name, goName, option)
+					}
+				}
+				if restricted {
+					f.Sortable, f.Filterable = sortable, filterable
+				}
+			}
+			result.Fields = append(result.Fields, f)
+		}
+
+		if rel, ok := tag.Lookup("alacarte"); ok {
+			r, err := parseRelation(goName, rel, astField.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, goName, err)
+			}
+			result.Relations = append(result.Relations, r)
+		}
+	}
+
+	return result, nil
+}
+
+func parseRelation(goName, tag string, typ ast.Expr) (relation, error) {
+	parts := strings.Split(tag, ",")
+	if len(parts) < 2 {
+		return relation{}, fmt.Errorf("relation tag %q needs a name and kind", tag)
+	}
+
+	r := relation{GoName: goName, Name: parts[0], Kind: parts[1], Key: "id"}
+	for _, option := range parts[2:] {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "fk":
+			r.ForeignKey = value
+		case "key":
+			r.Key = value
+		default:
+			return relation{}, fmt.Errorf("unknown relation option %q", option)
+		}
+	}
+	if r.ForeignKey == "" {
+		return relation{}, fmt.Errorf("relation %s needs an fk option", r.Name)
+	}
+
+	switch r.Kind {
+	case "hasmany":
+		slice, ok := typ.(*ast.ArrayType)
+		if !ok || slice.Len != nil {
+			return relation{}, fmt.Errorf("hasmany relation %s must be a slice", r.Name)
+		}
+		typ = slice.Elt
+	case "hasone", "belongsto":
+		if star, ok := typ.(*ast.StarExpr); ok {
+			r.Pointer, typ = true, star.X
+		}
+	default:
+		return relation{}, fmt.Errorf("unknown relation kind %q", r.Kind)
+	}
+
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return relation{}, fmt.Errorf("relation %s must refer to a struct in the same package", r.Name)
+	}
+	r.Target = ident.Name
+
+	return r, nil
+}
+
+func typeString(expr ast.Expr) string {
+	var buffer bytes.Buffer
+	_ = format.Node(&buffer, token.NewFileSet(), expr)
+	return buffer.String()
+}
+
+// =================
+// Output
+// =================
+
+type templateData struct {
+	Package string
+	File    string
+	Models  []modelData
+}
+
+type modelData struct {
+	*model
+	Relations []relationData
+}
+
+type relationData struct {
+	relation
+	Child *model
+	// Parent and child key fields, for belongsto the parent holds the foreign key.
+	ParentKey, ChildKey field
+	// KeyType is the type of the key functions, the child key is converted to it when the types differ.
+	KeyType string
+}
+
+func buildModel(m *model, models map[string]*model) (modelData, error) {
+	data := modelData{model: m}
+
+	for _, r := range m.Relations {
+		child, ok := models[r.Target]
+		if !ok {
+			return modelData{}, fmt.Errorf("%s.%s: %s has no %q directive", m.Name, r.GoName, r.Target,
+				strings.TrimSpace(directive))
+		}
+
+		out := relationData{relation: r, Child: child}
+
+		parentColumn, childColumn := r.Key, r.ForeignKey
+		if r.Kind == "belongsto" {
+			parentColumn, childColumn = r.ForeignKey, r.Key
+		}
+
+		var err error
+		if out.ParentKey, err = findField(m, parentColumn); err != nil {
+			return modelData{}, err
+		}
+		if out.ChildKey, err = findField(child, childColumn); err != nil {
+			return modelData{}, err
+		}
+		out.KeyType = strings.TrimPrefix(out.ParentKey.Type, "*")
+
+		data.Relations = append(data.Relations, out)
+	}
+
+	return data, nil
+}
+
+func findField(m *model, column string) (field, error) {
+	for _, f := range m.Fields {
+		if f.Column == column {
+			return f, nil
+		}
+	}
+	return field{}, fmt.Errorf("%s has no field with column %q", m.Name, column)
+}
+
+// key returns the body of a key function returning value as the key type. Nil pointers return the zero value.
+func key(value string, from, to string) string {
+	deref, pointer := strings.CutPrefix(from, "*")
+
+	result := value
+	if pointer {
+		result = "*" + result
+	}
+	if deref != to {
+		result = to + "(" + result + ")"
+	}
+
+	if pointer {
+		return "if " + value + " == nil {\nvar zero " + to + "\nreturn zero\n}\nreturn " + result
+	}
+	return "return " + result
+}
+
+var outputTemplate = template.Must(template.New("output").Funcs(template.FuncMap{
+	"key":   key,
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by alacarte-gen from {{ .File }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import "pollex.nl/alacarte"
+{{ range .Models }}{{ $model := . }}
+// The field and relation names of {{ .Name }}Schema.
+const (
+{{- range .Fields }}
+	{{ $model.Name }}Field{{ .GoName }} = {{ quote .Column }}
+{{- end }}
+{{- range .Relations }}
+	{{ $model.Name }}Field{{ .GoName }} = {{ quote .Name }}
+{{- end }}
+)
+
+// {{ .Name }}Schema is the schema of {{ .Name }} on the {{ .Table }} table.
+var {{ .Name }}Schema = alacarte.New[{{ .Name }}]({{ quote .Table }})
+{{- range .Fields }}.
+{{- if and .Sortable .Filterable (not .Hidden) }}
+	AddSimpleField({{ $model.Name }}Field{{ .GoName }}, func(t *{{ $model.Name }}) any { return &t.{{ .GoName }} })
+{{- else }}
+	AddFieldType({{ $model.Name }}Field{{ .GoName }}, alacarte.Field(
+		alacarte.Col({{ quote .Column }}),
+		alacarte.Ptr(func(t *{{ $model.Name }}) any { return &t.{{ .GoName }} }),
+	){{ if not .Sortable }}.NotSortable(){{ end }}{{ if not .Filterable }}.NotFilterable(){{ end }}{{ if .Hidden }}.Hide(){{ end }})
+{{- end }}
+{{- end }}
+{{ if .Relations }}
+// Relations are added in init, so schemas can refer to each other.
+func init() {
+{{- range .Relations }}
+	{{ $model.Name }}Schema.AddRelation({{ $model.Name }}Field{{ .GoName }},
+{{- if eq .Kind "belongsto" }}
+		alacarte.BelongsTo({{ .Target }}Schema,
+			{{ quote .ParentKey.Column }}, func(parent {{ $model.Name }}) {{ .KeyType }} { {{ key (print "parent." .ParentKey.GoName) .ParentKey.Type .KeyType }} },
+			{{ quote .ChildKey.Column }}, func(owner {{ .Target }}) {{ .KeyType }} { {{ key (print "owner." .ChildKey.GoName) .ChildKey.Type .KeyType }} },
+			func(parent *{{ $model.Name }}, owner {{ .Target }}) { parent.{{ .GoName }} = {{ if .Pointer }}&{{ end }}owner },
+		),
+{{- else }}
+		alacarte.{{ if eq .Kind "hasmany" }}HasManyByKey{{ else }}HasOneByKey{{ end }}({{ .Target }}Schema,
+			func(parent {{ $model.Name }}) {{ .KeyType }} { {{ key (print "parent." .ParentKey.GoName) .ParentKey.Type .KeyType }} },
+			func(child {{ .Target }}) {{ .KeyType }} { {{ key (print "child." .ChildKey.GoName) .ChildKey.Type .KeyType }} },
+{{- if eq .Kind "hasmany" }}
+			func(parent *{{ $model.Name }}, children []{{ .Target }}) { parent.{{ .GoName }} = children },
+{{- else }}
+			func(parent *{{ $model.Name }}, child {{ .Target }}) { parent.{{ .GoName }} = {{ if .Pointer }}&{{ end }}child },
+{{- end }}
+			alacarte.WhereIDs({{ quote .ChildKey.Column }}, func(parent {{ $model.Name }}) {{ .KeyType }} { {{ key (print "parent." .ParentKey.GoName) .ParentKey.Type .KeyType }} }),
+			alacarte.DependsOn(),
+		).Keys({{ quote .ParentKey.Column }}, {{ quote .ChildKey.Column }}){{ if eq .Kind "hasmany" }}.PartitionBy({{ quote .ChildKey.Column }}){{ end }},
+{{- end }}
+	)
+{{- end }}
+}
+{{ end }}
+{{- end }}`))
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateExample(t *testing.T) {
+	source, err := generate("../../example", "domain.go")
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("../../example/domain_alacarte.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(source), "example/domain_alacarte.go is outdated, run go generate")
+}
+
+func TestGenerateOptions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "models.go"), []byte(`package models
+
+//alacarte:table users
+type User struct {
+	ID      int64    `+"`db:\"id\"`"+`
+	Email   string   `+"`db:\"email,filterable\"`"+`
+	Token   string   `+"`db:\"token,hidden\"`"+`
+	TeamID  int64
+	Profile *Profile `+"`alacarte:\"profile,hasone,fk=user_id\"`"+`
+}
+
+//alacarte:table profiles
+type Profile struct {
+	UserID int64  `+"`db:\"\"`"+`
+	Bio    string `+"`db:\"bio\"`"+`
+}
+`), 0o644))
+
+	source, err := generate(dir, "models.go")
+	require.NoError(t, err)
+
+	code := string(source)
+	assert.Contains(t, code, `AddFieldType(UserFieldEmail, alacarte.Field(`)
+	assert.Contains(t, code, `).NotSortable())`)
+	assert.Contains(t, code, `AddFieldType(UserFieldToken, alacarte.Field(`)
+	assert.Contains(t, code, `).Hide())`)
+	assert.NotContains(t, code, "TeamID")
+	assert.Contains(t, code, `ProfileFieldUserID = "user_id"`)
+	assert.Contains(t, code, `alacarte.HasOneByKey(ProfileSchema,`)
+	assert.Contains(t, code, `parent.Profile = &child`)
+	assert.Contains(t, code, `.Keys("id", "user_id"),`)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown option":  "type A struct {\n\tID int64 `db:\"id,indexed\"`\n}",
+		"unknown kind":    "type A struct {\n\tID int64 `db:\"id\"`\n\tB  []A `alacarte:\"b,hasfew,fk=id\"`\n}",
+		"missing fk":      "type A struct {\n\tID int64 `db:\"id\"`\n\tB  []A `alacarte:\"b,hasmany\"`\n}",
+		"missing key":     "type A struct {\n\tID int64 `db:\"id\"`\n\tB  []A `alacarte:\"b,hasmany,fk=a_id\"`\n}",
+		"no schema":       "type A struct {\n\tID int64 `db:\"id\"`\n\tB  []B `alacarte:\"b,hasmany,fk=id\"`\n}\n\ntype B struct{}",
+		"hasmany no list": "type A struct {\n\tID int64 `db:\"id\"`\n\tB  A `alacarte:\"b,hasmany,fk=id\"`\n}",
+	}
+
+	for name, model := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := "package models\n\n//alacarte:table as\n" + model + "\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0o644))
+
+			_, err := generate(dir, "models.go")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Command alacarte-gen generates alacarte schemas for annotated structs. It is meant to be run by go generate:
+//
+//	//go:generate go run pollex.nl/alacarte/cmd/alacarte-gen
+//
+//	//alacarte:table authors
+//	type Author struct {
+//		ID    uint64 `db:"id"`
+//		Name  string `db:"name,sortable"`
+//		Books []Book `alacarte:"books,hasmany,fk=author_id"`
+//	}
+//
+// For every struct in the file with an alacarte:table directive it generates a schema variable (AuthorSchema),
+// constants with the field and relation names (AuthorFieldName) and the relations. Fields use the same `db` tags as
+// alacarte.FromStruct. Relations are declared with an `alacarte:"name,kind,options"` tag, where kind is one of:
+//
+//   - hasmany: a slice of children, fk is the child column referring to the parent.
+//   - hasone: a single child, fk is the child column referring to the parent.
+//   - belongsto: the owner of the parent, fk is the parent column referring to the owner.
+//
+// The key option sets the referenced column, which defaults to "id". The related struct must have a schema as well.
+//
+// The output is written to <file>_alacarte.go.
+//
+// For existing databases the annotated structs can be generated from a SQLite file:
+//
+//	alacarte-gen -sqlite legacy.db -package models -file models/models.go
+//
+// This writes a struct for every table, with relations for the foreign keys, and generates the schemas for them.
+// Edit the structs as needed and use go generate to update the schemas.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	var (
+		input  = flag.String("file", os.Getenv("GOFILE"), "go file with the annotated structs, defaults to $GOFILE")
+		output = flag.String("output", "", "output file, defaults to <file>_alacarte.go")
+		sqlite = flag.String("sqlite", "", "sqlite database to generate the structs in file from")
+		pkg    = flag.String("package", os.Getenv("GOPACKAGE"), "package of the structs generated with -sqlite")
+	)
+	flag.Parse()
+
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "alacarte-gen: no input file, use -file or run with go generate")
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*input, ".go") + "_alacarte.go"
+	}
+
+	if *sqlite != "" {
+		if err := generateSQLite(*sqlite, *pkg, *input); err != nil {
+			fmt.Fprintln(os.Stderr, "alacarte-gen:", err)
+			os.Exit(1)
+		}
+	}
+
+	source, err := generate(filepath.Dir(*input), filepath.Base(*input))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "alacarte-gen:", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "alacarte-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// generateSQLite writes the annotated structs for the tables in the database to file.
+func generateSQLite(database, pkg, file string) error {
+	if pkg == "" {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		pkg = filepath.Base(filepath.Dir(abs))
+	}
+
+	// Open read only, so a missing file is not created
+	db, err := sql.Open("sqlite3", "file:"+database+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := introspect(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	source, err := structs(pkg, filepath.Base(database), modelsFromTables(tables))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, source, 0o644)
+}
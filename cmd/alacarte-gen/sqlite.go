@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type table struct {
+	Name        string
+	Columns     []column
+	ForeignKeys []foreignKey
+}
+
+type column struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+	Unique     bool
+}
+
+type foreignKey struct {
+	Column string
+	Table  string
+	Refers string
+}
+
+// introspect reads the tables, columns and foreign keys of a SQLite database.
+func introspect(ctx context.Context, db *sql.DB) ([]table, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]table, 0, len(names))
+	for _, name := range names {
+		t := table{Name: name}
+		if t.Columns, err = tableColumns(ctx, db, name); err != nil {
+			return nil, err
+		}
+		if t.ForeignKeys, err = tableForeignKeys(ctx, db, name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+
+	for ix := range tables {
+		// Keep the order of the columns, rather than the reversed order of the declarations
+		position := map[string]int{}
+		for colIx, col := range tables[ix].Columns {
+			position[col.Name] = colIx
+		}
+		sort.SliceStable(tables[ix].ForeignKeys, func(i, j int) bool {
+			return position[tables[ix].ForeignKeys[i].Column] < position[tables[ix].ForeignKeys[j].Column]
+		})
+
+		// Foreign keys without a column refer to the primary key
+		for fkIx, fk := range tables[ix].ForeignKeys {
+			if fk.Refers != "" {
+				continue
+			}
+			for _, other := range tables {
+				if other.Name != fk.Table {
+					continue
+				}
+				for _, col := range other.Columns {
+					if col.PrimaryKey {
+						tables[ix].ForeignKeys[fkIx].Refers = col.Name
+					}
+				}
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+func tableColumns(ctx context.Context, db *sql.DB, name string) ([]column, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", pk FROM pragma_table_info(?)", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		columns     []column
+		primaryKeys int
+	)
+	for rows.Next() {
+		var (
+			col column
+			pk  int
+		)
+		if err := rows.Scan(&col.Name, &col.Type, &col.NotNull, &pk); err != nil {
+			return nil, err
+		}
+		col.PrimaryKey = pk > 0
+		if col.PrimaryKey {
+			primaryKeys++
+		}
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	unique, err := uniqueColumns(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	for ix, col := range columns {
+		columns[ix].Unique = unique[col.Name] || (col.PrimaryKey && primaryKeys == 1)
+	}
+
+	return columns, nil
+}
+
+// uniqueColumns returns the columns with a unique index on only that column.
+func uniqueColumns(ctx context.Context, db *sql.DB, name string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT MIN(info.name) FROM pragma_index_list(?) list, pragma_index_info(list.name) info
+		WHERE list."unique" = 1 GROUP BY list.name HAVING COUNT(*) = 1`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unique := map[string]bool{}
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		unique[col] = true
+	}
+
+	return unique, rows.Err()
+}
+
+func tableForeignKeys(ctx context.Context, db *sql.DB, name string) ([]foreignKey, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, "from", "table", COALESCE("to", ''), (SELECT COUNT(*) FROM pragma_foreign_key_list(?1) other WHERE other.id = fk.id)
+		FROM pragma_foreign_key_list(?1) fk ORDER BY id, seq`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foreignKeys []foreignKey
+	for rows.Next() {
+		var (
+			fk      foreignKey
+			id      int
+			columns int
+		)
+		if err := rows.Scan(&id, &fk.Column, &fk.Table, &fk.Refers, &columns); err != nil {
+			return nil, err
+		}
+		// Relations on multiple columns can not be expressed with a single key
+		if columns > 1 {
+			continue
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	return foreignKeys, rows.Err()
+}
+
+// modelsFromTables converts tables to models, with relations for every foreign key. The referenced table gets a
+// hasmany relation, or a hasone relation if the foreign key is unique, and the referencing table a belongsto relation.
+func modelsFromTables(tables []table) []*model {
+	models := make([]*model, len(tables))
+	byTable := map[string]*model{}
+	columns := map[string]map[string]column{}
+	for ix, t := range tables {
+		m := &model{Name: goName(singular(t.Name)), Table: t.Name}
+		columns[t.Name] = map[string]column{}
+		for _, col := range t.Columns {
+			m.Fields = append(m.Fields, field{
+				GoName:     goName(col.Name),
+				Column:     col.Name,
+				Type:       goType(col),
+				Sortable:   true,
+				Filterable: true,
+			})
+			columns[t.Name][col.Name] = col
+		}
+		models[ix] = m
+		byTable[t.Name] = m
+	}
+
+	// names holds the used field names per model, relations must not collide with columns or other relations
+	names := map[*model]map[string]bool{}
+	for _, m := range models {
+		names[m] = map[string]bool{}
+		for _, f := range m.Fields {
+			names[m][f.Column] = true
+		}
+	}
+	uniqueName := func(m *model, name, alternative string) string {
+		if names[m][name] {
+			name = alternative
+		}
+		for names[m][name] {
+			name += "_rel"
+		}
+		names[m][name] = true
+		return name
+	}
+
+	for _, t := range tables {
+		child := byTable[t.Name]
+		for _, fk := range t.ForeignKeys {
+			parent, ok := byTable[fk.Table]
+			if !ok || fk.Refers == "" {
+				continue
+			}
+			// Columns such as editor_id name the relation editor, others are named after the referenced table
+			owner := strings.TrimSuffix(fk.Column, "_id")
+			if owner == fk.Column {
+				owner = singular(fk.Table)
+			}
+
+			name := uniqueName(child, owner, singular(fk.Table)+"_by_"+fk.Column)
+			child.Relations = append(child.Relations, relation{
+				GoName: goName(name), Name: name, Kind: "belongsto",
+				Target: parent.Name, Pointer: true, Key: fk.Refers, ForeignKey: fk.Column,
+			})
+
+			r := relation{Target: child.Name, Key: fk.Refers, ForeignKey: fk.Column, Kind: "hasmany"}
+			if columns[t.Name][fk.Column].Unique {
+				r.Kind, r.Pointer = "hasone", true
+				r.Name = uniqueName(parent, singular(t.Name), singular(t.Name)+"_by_"+owner)
+			} else {
+				r.Name = uniqueName(parent, t.Name, t.Name+"_by_"+owner)
+			}
+			r.GoName = goName(r.Name)
+			parent.Relations = append(parent.Relations, r)
+		}
+	}
+
+	return models
+}
+
+// goType maps the declared type of a column to a Go type, following the type affinity rules of SQLite. Columns that
+// can be null are pointers.
+func goType(col column) string {
+	declared := strings.ToUpper(col.Type)
+
+	var typ string
+	switch {
+	case strings.Contains(declared, "INT"):
+		typ = "int64"
+	case strings.Contains(declared, "CHAR"), strings.Contains(declared, "CLOB"), strings.Contains(declared, "TEXT"):
+		typ = "string"
+	case strings.Contains(declared, "BLOB"), declared == "":
+		typ = "[]byte"
+	case strings.Contains(declared, "REAL"), strings.Contains(declared, "FLOA"), strings.Contains(declared, "DOUB"):
+		typ = "float64"
+	case strings.Contains(declared, "BOOL"):
+		typ = "bool"
+	case strings.Contains(declared, "DATE"), strings.Contains(declared, "TIME"):
+		typ = "time.Time"
+	default:
+		typ = "float64"
+	}
+
+	if !col.NotNull && !col.PrimaryKey && typ != "[]byte" {
+		typ = "*" + typ
+	}
+	return typ
+}
+
+var initialisms = map[string]string{"id": "ID", "url": "URL", "uuid": "UUID", "api": "API", "http": "HTTP", "json": "JSON"}
+
+// goName converts a snake case name such as author_id to AuthorID.
+func goName(name string) string {
+	var builder strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if initialism, ok := initialisms[strings.ToLower(part)]; ok {
+			builder.WriteString(initialism)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	result := builder.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "T" + result
+	}
+	return result
+}
+
+// singular naively converts a plural table name to a singular name.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"),
+		strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// structs returns the source of the structs for the models, annotated for generate.
+func structs(pkg, source string, models []*model) ([]byte, error) {
+	var usesTime bool
+	for _, m := range models {
+		for _, f := range m.Fields {
+			usesTime = usesTime || strings.HasSuffix(f.Type, "time.Time")
+		}
+	}
+
+	var buffer bytes.Buffer
+	err := structsTemplate.Execute(&buffer, map[string]any{
+		"Package": pkg, "Source": source, "Models": models, "Time": usesTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buffer.String())
+	}
+	return result, nil
+}
+
+var structsTemplate = template.Must(template.New("structs").Funcs(template.FuncMap{
+	"quote": func(s string) string { return "`" + s + "`" },
+}).Parse(`// Generated by alacarte-gen from {{ .Source }}, run go generate after editing.
+
+package {{ .Package }}
+{{ if .Time }}
+import "time"
+{{ end }}
+//go:generate go run pollex.nl/alacarte/cmd/alacarte-gen
+{{ range .Models }}
+//alacarte:table {{ .Table }}
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .GoName }} {{ .Type }} {{ quote (print "db:\"" .Column "\"") }}
+{{- end }}
+{{ if .Relations }}
+{{ end }}
+{{- range .Relations }}
+	{{ .GoName }} {{ if eq .Kind "hasmany" }}[]{{ else if .Pointer }}*{{ end }}{{ .Target }} {{ quote (print "alacarte:\"" .Name "," .Kind ",fk=" .ForeignKey (or (and (ne .Key "id") (print ",key=" .Key)) "") "\"") }}
+{{- end }}
+}
+{{ end }}`))
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacySchema = `
+CREATE TABLE authors (
+	id INTEGER PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	born DATETIME
+);
+CREATE TABLE profiles (
+	id INTEGER PRIMARY KEY,
+	author_id INTEGER NOT NULL UNIQUE REFERENCES authors(id),
+	bio TEXT
+);
+CREATE TABLE categories (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	parent_id INTEGER REFERENCES categories
+);
+CREATE TABLE books (
+	id INTEGER PRIMARY KEY,
+	title TEXT NOT NULL,
+	price REAL NOT NULL,
+	author_id INTEGER NOT NULL REFERENCES authors(id),
+	editor_id INTEGER REFERENCES authors(id),
+	category_id INTEGER REFERENCES categories(id)
+);
+`
+
+func setupSQLite(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(legacySchema)
+	require.NoError(t, err)
+
+	return path
+}
+
+func TestIntrospect(t *testing.T) {
+	db, err := sql.Open("sqlite3", setupSQLite(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	tables, err := introspect(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, tables, 4)
+
+	books := tables[1]
+	assert.Equal(t, "books", books.Name)
+	assert.Equal(t, []foreignKey{
+		{Column: "author_id", Table: "authors", Refers: "id"},
+		{Column: "editor_id", Table: "authors", Refers: "id"},
+		{Column: "category_id", Table: "categories", Refers: "id"},
+	}, books.ForeignKeys)
+
+	categories := tables[2]
+	assert.Equal(t, []foreignKey{{Column: "parent_id", Table: "categories", Refers: "id"}}, categories.ForeignKeys)
+
+	profiles := tables[3]
+	assert.True(t, profiles.Columns[1].Unique)
+	assert.False(t, books.Columns[3].Unique)
+}
+
+func TestModelsFromTables(t *testing.T) {
+	db, err := sql.Open("sqlite3", setupSQLite(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	tables, err := introspect(context.Background(), db)
+	require.NoError(t, err)
+
+	source, err := structs("legacy", "legacy.db", modelsFromTables(tables))
+	require.NoError(t, err)
+
+	// Ignore the alignment of the fields
+	code := strings.Join(strings.Fields(string(source)), " ")
+	assert.Contains(t, code, "//alacarte:table authors type Author struct {")
+	assert.Contains(t, code, "Born *time.Time `db:\"born\"`")
+	assert.Contains(t, code, "Price float64 `db:\"price\"`")
+	assert.Contains(t, code, "EditorID *int64 `db:\"editor_id\"`")
+	assert.Contains(t, code, "Books []Book `alacarte:\"books,hasmany,fk=author_id\"`")
+	assert.Contains(t, code, "BooksByEditor []Book `alacarte:\"books_by_editor,hasmany,fk=editor_id\"`")
+	assert.Contains(t, code, "Profile *Profile `alacarte:\"profile,hasone,fk=author_id\"`")
+	assert.Contains(t, code, "Editor *Author `alacarte:\"editor,belongsto,fk=editor_id\"`")
+	assert.Contains(t, code, "Parent *Category `alacarte:\"parent,belongsto,fk=parent_id\"`")
+	assert.Contains(t, code, "Categories []Category `alacarte:\"categories,hasmany,fk=parent_id\"`")
+}
+
+// TestGenerateSQLite generates a package from a database and type checks the result with go vet.
+func TestGenerateSQLite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated package")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+
+	// The package has to be in the module to import alacarte, testdata is ignored by ./...
+	dir, err := os.MkdirTemp("testdata", "legacy")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, "models.go")
+	require.NoError(t, generateSQLite(setupSQLite(t), "", file))
+
+	source, err := generate(dir, "models.go")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "models_alacarte.go"), source, 0o644))
+
+	code := string(source)
+	assert.Contains(t, code, `alacarte.BelongsTo(AuthorSchema,`)
+	assert.Contains(t, code, `alacarte.HasOneByKey(ProfileSchema,`)
+	assert.Contains(t, code, "if parent.EditorID == nil {")
+
+	output, err := exec.Command(goCmd, "vet", "./"+dir).CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
@@ -0,0 +1,15 @@
+// Command alacarte-vet checks the field paths passed to alacarte Query, Select and DependsOn, see the fieldcheck
+// package. Run it standalone or with go vet:
+//
+//	go install pollex.nl/alacarte/cmd/alacarte-vet
+//	go vet -vettool=$(which alacarte-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+	"pollex.nl/alacarte/fieldcheck"
+)
+
+func main() {
+	singlechecker.Main(fieldcheck.Analyzer)
+}
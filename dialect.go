@@ -0,0 +1,41 @@
+package alacarte
+
+// Dialect describes the limits of a database that affect the generated queries.
+type Dialect struct {
+	// MaxParams is the maximum number of bound parameters in a single query.
+	MaxParams int
+}
+
+var (
+	// SQLite is limited to 999 parameters before version 3.32, and 32766 after.
+	SQLite = Dialect{MaxParams: 999}
+	// Postgres is limited to 65535 parameters by its wire protocol.
+	Postgres = Dialect{MaxParams: 65535}
+	// MySQL is limited to 65535 parameters in prepared statements.
+	MySQL = Dialect{MaxParams: 65535}
+
+	// DefaultDialect is used by queries that do not set a dialect.
+	DefaultDialect = SQLite
+)
+
+// reservedParams are left for the parameters of filters and other query mods in a chunked query.
+const reservedParams = 100
+
+// ChunkSize returns the number of parent keys that fit in the IN list of a single relation query.
+func (dialect Dialect) ChunkSize() int {
+	return max(dialect.MaxParams-reservedParams, 1)
+}
+
+// chunk splits items in chunks of at most size items. A size of zero or less does not split.
+func chunk[E any](items []E, size int) [][]E {
+	if size <= 0 || len(items) <= size {
+		return [][]E{items}
+	}
+
+	var chunks [][]E
+	for size < len(items) {
+		items, chunks = items[size:], append(chunks, items[:size:size])
+	}
+
+	return append(chunks, items)
+}
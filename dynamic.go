@@ -0,0 +1,168 @@
+package alacarte
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Row is a model of a DynamicSchema, holding the selected fields by name. Relations are nested as []Row or Row.
+type Row = map[string]any
+
+// Type creates the scan destination of a dynamic field, and returns the scanned value.
+type Type func() (dest any, value func() any)
+
+// TypeOf is the Type of fields scanned into a V, use a pointer type for columns that can be null.
+func TypeOf[V any]() Type {
+	return func() (any, func() any) {
+		var value V
+		return &value, func() any { return value }
+	}
+}
+
+var (
+	Int    = TypeOf[int64]()
+	Float  = TypeOf[float64]()
+	String = TypeOf[string]()
+	Bool   = TypeOf[bool]()
+	Time   = TypeOf[time.Time]()
+	Bytes  = TypeOf[[]byte]()
+)
+
+// DynamicSchema is a schema without a Go struct, its models are Rows. Fields are declared by column and Type, and
+// selections, filters, relations and query mods work like they do for any other schema. Key fields of relations are
+// selected automatically and are included in the rows.
+type DynamicSchema struct {
+	*ModelSchema[Row]
+}
+
+func NewDynamic(table string) *DynamicSchema {
+	return &DynamicSchema{ModelSchema: New[Row](table)}
+}
+
+// AddColumn adds a field that scans the column into a value of the given type.
+func (schema *DynamicSchema) AddColumn(name, column string, typ Type) *DynamicSchema {
+	schema.AddFieldType(name, Field(Col(column), func(row *Row) (Ptrs, Action) {
+		dest, value := typ()
+		return Ptrs{dest}, func() { setRow(row, name, value()) }
+	}))
+
+	return schema
+}
+
+// AddRelation adds a relation, see HasMany, HasOne and BelongsTo for relations between dynamic schemas.
+func (schema *DynamicSchema) AddRelation(name string, relation Relation[Row]) *DynamicSchema {
+	schema.ModelSchema.AddRelation(name, relation)
+
+	return schema
+}
+
+// HasMany adds a relation to the children whose childKey field refers to the parentKey field, e.g. "id" and
+// "author_id" for the books of an author. The children are assigned as []Row, which is empty rather than nil without
+// children.
+func (schema *DynamicSchema) HasMany(name string, child *DynamicSchema, parentKey, childKey string) *DynamicSchema {
+	schema.checkKeyColumn(name, child, childKey)
+
+	return schema.AddRelation(name,
+		HasManyByKey(child.ModelSchema,
+			rowKey(parentKey),
+			rowKey(childKey),
+			func(parent *Row, children []Row) {
+				if children == nil {
+					children = []Row{}
+				}
+				setRow(parent, name, children)
+			},
+			child.whereKeys(childKey, rowKey(parentKey)),
+			DependsOn(),
+		).Keys(parentKey, childKey).PartitionBy(childKey),
+	)
+}
+
+// HasOne is HasMany for a single child, which is assigned as a Row.
+func (schema *DynamicSchema) HasOne(name string, child *DynamicSchema, parentKey, childKey string) *DynamicSchema {
+	schema.checkKeyColumn(name, child, childKey)
+
+	return schema.AddRelation(name,
+		HasOneByKey(child.ModelSchema,
+			rowKey(parentKey),
+			rowKey(childKey),
+			func(parent *Row, child Row) { setRow(parent, name, child) },
+			child.whereKeys(childKey, rowKey(parentKey)),
+			DependsOn(),
+		).Keys(parentKey, childKey),
+	)
+}
+
+// BelongsTo adds a relation to the owner that the foreignKey field refers to, e.g. "author_id" and "id" for the author
+// of a book. The owner is assigned as a Row.
+func (schema *DynamicSchema) BelongsTo(name string, owner *DynamicSchema, foreignKey, ownerKey string) *DynamicSchema {
+	return schema.AddRelation(name,
+		BelongsTo(owner.ModelSchema,
+			foreignKey, rowKey(foreignKey),
+			ownerKey, rowKey(ownerKey),
+			func(parent *Row, owner Row) { setRow(parent, name, owner) },
+		),
+	)
+}
+
+// checkKeyColumn reports child keys that do not select a single column, as the children are filtered on that column.
+// Child keys that do not exist are reported by AddRelation.
+func (schema *DynamicSchema) checkKeyColumn(name string, child *DynamicSchema, childKey string) {
+	if !child.hasField(childKey) {
+		return
+	}
+	if _, err := child.fieldColumn(childKey, child.Table); err != nil {
+		schema.errors = append(schema.errors, fmt.Errorf("%w of relation %s", err, name))
+	}
+}
+
+// whereKeys filters the rows on the column of the key field, which can differ from its name, like WhereIDs does for
+// columns. Invalid key fields are reported by checkKeyColumn, the filter matches nothing for them.
+func (schema *DynamicSchema) whereKeys(field string, getKey func(Row) any) func(parents []Row) QueryMod {
+	return func(parents []Row) QueryMod {
+		keys := make([]any, len(parents))
+		for ix, parent := range parents {
+			keys[ix] = getKey(parent)
+		}
+
+		return func(q Q, table string) Q {
+			cond, err := schema.filterSql(In(field, keys...), table)
+			if err != nil {
+				return q.Where(squirrel.Expr("1 = 0"))
+			}
+			return q.Where(cond)
+		}
+	}
+}
+
+func setRow(row *Row, name string, value any) {
+	if *row == nil {
+		*row = Row{}
+	}
+	(*row)[name] = value
+}
+
+// rowKey returns the value of a key field, so nullable and non-nullable keys of the same type match.
+func rowKey(field string) func(Row) any {
+	return func(row Row) any {
+		value := reflect.ValueOf(row[field])
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				return nil
+			}
+			value = value.Elem()
+		}
+		if !value.IsValid() {
+			return nil
+		}
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices can not be used as map key
+			return string(value.Bytes())
+		}
+
+		return value.Interface()
+	}
+}
@@ -0,0 +1,89 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestDynamicSchema(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff", "a,b").Values(2, "Mo", "c").Exec()
+	sq.Insert("books").Values(1, "Life of Jeff", 1).Values(2, "Cooking like Jeff", 1).Values(3, "Orphan", nil).Exec()
+
+	authors := alacarte.NewDynamic("authors").
+		AddColumn("id", "id", alacarte.Int).
+		AddColumn("name", "name", alacarte.String)
+	books := alacarte.NewDynamic("books").
+		AddColumn("id", "id", alacarte.Int).
+		AddColumn("title", "name", alacarte.String).
+		AddColumn("author_id", "author_id", alacarte.TypeOf[*int64]()).
+		BelongsTo("author", authors, "author_id", "id")
+	authors.HasMany("books", books, "id", "author_id")
+
+	t.Run("returns rows with nested relations", func(t *testing.T) {
+		rows, err := authors.Query("name", "books(order:id).title").
+			Where(alacarte.Eq("id", 1)).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, rows, 1)
+		assert.Equal(t, "Jeff", rows[0]["name"])
+
+		children := rows[0]["books"].([]alacarte.Row)
+		require.Len(t, children, 2)
+		assert.Equal(t, "Life of Jeff", children[0]["title"])
+		assert.Equal(t, "Cooking like Jeff", children[1]["title"])
+	})
+
+	t.Run("belongs to with nullable keys", func(t *testing.T) {
+		rows, err := books.Query("title", "author.name").OrderBy("id").Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, rows, 3)
+		assert.Equal(t, "Jeff", rows[0]["author"].(alacarte.Row)["name"])
+		assert.Nil(t, rows[2]["author_id"])
+		assert.NotContains(t, rows[2], "author")
+	})
+
+	t.Run("marshals to JSON", func(t *testing.T) {
+		rows, err := authors.Query("name", "books.title").Where(alacarte.Eq("id", 2)).Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(rows)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"id": 2, "name": "Mo", "books": []}]`, string(data))
+	})
+
+	t.Run("key field with another column name", func(t *testing.T) {
+		writers := alacarte.NewDynamic("authors").AddColumn("id", "id", alacarte.Int)
+		works := alacarte.NewDynamic("books").
+			AddColumn("title", "name", alacarte.String).
+			AddColumn("writer", "author_id", alacarte.TypeOf[*int64]())
+		writers.HasMany("works", works, "id", "writer").HasOne("work", works, "id", "writer")
+		require.NoError(t, writers.Err())
+
+		rows, err := writers.Query("works(order:title,limit:1).title", "work.title").
+			Where(alacarte.Eq("id", 1)).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, rows, 1)
+		children := rows[0]["works"].([]alacarte.Row)
+		require.Len(t, children, 1)
+		assert.Equal(t, "Cooking like Jeff", children[0]["title"])
+		assert.Equal(t, int64(1), *children[0]["writer"].(*int64))
+		assert.Contains(t, rows[0]["work"].(alacarte.Row)["title"], "Jeff")
+	})
+
+	t.Run("validates selections", func(t *testing.T) {
+		_, err := authors.Query("title").Collect(context.Background(), db)
+		assert.ErrorIs(t, err, alacarte.ErrNoSuchField)
+	})
+}
@@ -1,25 +1,30 @@
 package example
 
+//go:generate go run pollex.nl/alacarte/cmd/alacarte-gen
+
+//alacarte:table authors
 type Author struct {
-	ID   uint64
-	Name string
+	ID   uint64 `db:"id"`
+	Name string `db:"name"`
 
-	Books []Book
+	Books []Book `alacarte:"books,hasmany,fk=author_id"`
 }
 
+//alacarte:table books
 type Book struct {
-	ID   uint64
-	Name string
+	ID   uint64 `db:"id"`
+	Name string `db:"name"`
 
-	AuthorID int64
-	Author   Author
-	GenreID  int64
-	Genre    Genre
+	AuthorID int64  `db:"author_id"`
+	Author   Author `alacarte:"author,belongsto,fk=author_id"`
+	GenreID  int64  `db:"genre_id"`
+	Genre    Genre  `alacarte:"genre,belongsto,fk=genre_id"`
 }
 
+//alacarte:table genres
 type Genre struct {
-	ID   uint64
-	Name string
+	ID   uint64 `db:"id"`
+	Name string `db:"name"`
 
-	Books []Book
+	Books []Book `alacarte:"books,hasmany,fk=genre_id"`
 }
@@ -0,0 +1,90 @@
+// Code generated by alacarte-gen from domain.go; DO NOT EDIT.
+
+package example
+
+import "pollex.nl/alacarte"
+
+// The field and relation names of AuthorSchema.
+const (
+	AuthorFieldID    = "id"
+	AuthorFieldName  = "name"
+	AuthorFieldBooks = "books"
+)
+
+// AuthorSchema is the schema of Author on the authors table.
+var AuthorSchema = alacarte.New[Author]("authors").
+	AddSimpleField(AuthorFieldID, func(t *Author) any { return &t.ID }).
+	AddSimpleField(AuthorFieldName, func(t *Author) any { return &t.Name })
+
+// Relations are added in init, so schemas can refer to each other.
+func init() {
+	AuthorSchema.AddRelation(AuthorFieldBooks,
+		alacarte.HasManyByKey(BookSchema,
+			func(parent Author) uint64 { return parent.ID },
+			func(child Book) uint64 { return uint64(child.AuthorID) },
+			func(parent *Author, children []Book) { parent.Books = children },
+			alacarte.WhereIDs("author_id", func(parent Author) uint64 { return parent.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "author_id").PartitionBy("author_id"),
+	)
+}
+
+// The field and relation names of BookSchema.
+const (
+	BookFieldID       = "id"
+	BookFieldName     = "name"
+	BookFieldAuthorID = "author_id"
+	BookFieldGenreID  = "genre_id"
+	BookFieldAuthor   = "author"
+	BookFieldGenre    = "genre"
+)
+
+// BookSchema is the schema of Book on the books table.
+var BookSchema = alacarte.New[Book]("books").
+	AddSimpleField(BookFieldID, func(t *Book) any { return &t.ID }).
+	AddSimpleField(BookFieldName, func(t *Book) any { return &t.Name }).
+	AddSimpleField(BookFieldAuthorID, func(t *Book) any { return &t.AuthorID }).
+	AddSimpleField(BookFieldGenreID, func(t *Book) any { return &t.GenreID })
+
+// Relations are added in init, so schemas can refer to each other.
+func init() {
+	BookSchema.AddRelation(BookFieldAuthor,
+		alacarte.BelongsTo(AuthorSchema,
+			"author_id", func(parent Book) int64 { return parent.AuthorID },
+			"id", func(owner Author) int64 { return int64(owner.ID) },
+			func(parent *Book, owner Author) { parent.Author = owner },
+		),
+	)
+	BookSchema.AddRelation(BookFieldGenre,
+		alacarte.BelongsTo(GenreSchema,
+			"genre_id", func(parent Book) int64 { return parent.GenreID },
+			"id", func(owner Genre) int64 { return int64(owner.ID) },
+			func(parent *Book, owner Genre) { parent.Genre = owner },
+		),
+	)
+}
+
+// The field and relation names of GenreSchema.
+const (
+	GenreFieldID    = "id"
+	GenreFieldName  = "name"
+	GenreFieldBooks = "books"
+)
+
+// GenreSchema is the schema of Genre on the genres table.
+var GenreSchema = alacarte.New[Genre]("genres").
+	AddSimpleField(GenreFieldID, func(t *Genre) any { return &t.ID }).
+	AddSimpleField(GenreFieldName, func(t *Genre) any { return &t.Name })
+
+// Relations are added in init, so schemas can refer to each other.
+func init() {
+	GenreSchema.AddRelation(GenreFieldBooks,
+		alacarte.HasManyByKey(BookSchema,
+			func(parent Genre) uint64 { return parent.ID },
+			func(child Book) uint64 { return uint64(child.GenreID) },
+			func(parent *Genre, children []Book) { parent.Books = children },
+			alacarte.WhereIDs("genre_id", func(parent Genre) uint64 { return parent.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "genre_id").PartitionBy("genre_id"),
+	)
+}
@@ -4,9 +4,10 @@ import (
 	"pollex.nl/alacarte"
 )
 
-var AuthorSchema = alacarte.New[Author]("authors").
-	AddField("id", alacarte.Col("id"), alacarte.Ptr(func(t *Author) any { return t.ID })).
-	AddField("name", alacarte.Col("name"), alacarte.Ptr(func(t *Author) any { return t.Name })).
+// DBAuthor is AuthorSchema written by hand, for comparison with the generated schemas in domain_alacarte.go.
+var DBAuthor = alacarte.New[Author]("authors").
+	AddField("id", alacarte.Col("id"), alacarte.Ptr(func(t *Author) any { return &t.ID })).
+	AddField("name", alacarte.Col("name"), alacarte.Ptr(func(t *Author) any { return &t.Name })).
 	AddRelation("books",
 		alacarte.HasMany(
 			DBBook,
@@ -20,5 +21,6 @@ var AuthorSchema = alacarte.New[Author]("authors").
 	)
 
 var DBBook = alacarte.New[Book]("books").
-	AddField("id", alacarte.Col("id"), alacarte.Ptr(func(t *Book) any { return t.ID })).
-	AddField("name", alacarte.Col("name"), alacarte.Ptr(func(t *Book) any { return t.Name }))
+	AddField("id", alacarte.Col("id"), alacarte.Ptr(func(t *Book) any { return &t.ID })).
+	AddField("name", alacarte.Col("name"), alacarte.Ptr(func(t *Book) any { return &t.Name })).
+	AddField("author_id", alacarte.Col("author_id"), alacarte.Ptr(func(t *Book) any { return &t.AuthorID }))
@@ -3,6 +3,11 @@ package example
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/httpsel"
 )
 
 type Store struct {
@@ -24,3 +29,21 @@ func (store *Store) ListAuthors(ctx context.Context, opts Opts) ([]Author, error
 
 	return authors, nil
 }
+
+// ServeAuthors lists the authors with the selection of the request, e.g. `?fields=id,name&expand=books.genre`.
+func (store *Store) ServeAuthors(w http.ResponseWriter, r *http.Request) {
+	query, err := httpsel.FromRequest(AuthorSchema, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authors, err := query.Collect(r.Context(), store.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(alacarte.Sparse(authors, query.Selection()))
+}
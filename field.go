@@ -7,9 +7,18 @@ type (
 	FieldType[T any] struct {
 		Mod     QueryMod
 		RowScan RowScan[T]
+		// Unsortable fields can not be used in ModelQuery.OrderBy
+		Unsortable bool
+		// Unfilterable fields can not be used in ModelQuery.Where
+		Unfilterable bool
+		// Hidden fields are only selected when they are named, not by "*" or an empty selection
+		Hidden bool
 	}
 )
 
+// scanner scans the fields of a query, followed by the given pointers for extra columns.
+type scanner[T any] func(t *T, extra ...any) (Ptrs, Action)
+
 func Ptr[T any](ptr func(t *T) any) RowScan[T] {
 	return func(t *T) (Ptrs, Action) {
 		return Ptrs{ptr(t)}, nil
@@ -17,7 +26,29 @@ func Ptr[T any](ptr func(t *T) any) RowScan[T] {
 }
 
 func Field[T any](mod QueryMod, scan RowScan[T]) FieldType[T] {
-	return FieldType[T]{mod, scan}
+	return FieldType[T]{Mod: mod, RowScan: scan}
+}
+
+// NotSortable marks the field as unsortable, for example when the scanned column is post-processed.
+func (field FieldType[T]) NotSortable() FieldType[T] {
+	field.Unsortable = true
+
+	return field
+}
+
+// NotFilterable marks the field as unfilterable, for example when filtering on the column is too expensive.
+func (field FieldType[T]) NotFilterable() FieldType[T] {
+	field.Unfilterable = true
+
+	return field
+}
+
+// Hide hides the field from "*" and empty selections, for example for password hashes or large columns. It is still
+// selected when it is named.
+func (field FieldType[T]) Hide() FieldType[T] {
+	field.Hidden = true
+
+	return field
 }
 
 func flattenRowScan[T any](rowScans []RowScan[T]) RowScan[T] {
@@ -0,0 +1,516 @@
+// Package fieldcheck defines an analyzer that checks the field paths given to alacarte schemas at compile time.
+//
+// It tracks package-level schema variables created with alacarte.New or alacarte.FromStruct, together with the fields
+// and relations added to them, and reports string literals passed to Query, Select, DependsOn, AddPreset and SetDefault
+// that do not resolve to a field, relation or preset:
+//
+//	AuthorSchema.Query("id", "boks.name") // "boks" is not a field or relation of AuthorSchema
+//
+// Schemas of imported packages are checked as well. Schemas with field names that are not constant, or relations to
+// schemas that can not be tracked, are only checked as far as they are known. Run it with cmd/alacarte-vet.
+package fieldcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+	"pollex.nl/alacarte/internal/naming"
+)
+
+const alacartePath = "pollex.nl/alacarte"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "alacarte",
+	Doc:       "check field paths passed to alacarte Query, Select, DependsOn, AddPreset and SetDefault",
+	URL:       "https://pkg.go.dev/pollex.nl/alacarte/fieldcheck",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(SchemaFact)},
+}
+
+// SchemaFact describes a package-level schema variable, so schemas can be checked across packages.
+type SchemaFact struct {
+	Fields    []string
+	Relations map[string]SchemaRef
+	Presets   []string
+	// Complete is false if fields or relations were added with names that are not constant.
+	Complete bool
+}
+
+// SchemaRef refers to the package-level schema variable of a relation. It is empty if the schema is not known.
+type SchemaRef struct {
+	Pkg  string
+	Name string
+}
+
+func (*SchemaFact) AFact() {}
+
+func (fact *SchemaFact) String() string {
+	return fmt.Sprintf("schema(%d fields, %d relations)", len(fact.Fields), len(fact.Relations))
+}
+
+// schema is a tracked schema variable.
+type schema struct {
+	name      string
+	fields    map[string]bool
+	relations map[string]types.Object // nil if the child schema is not known
+	presets   map[string]bool
+	complete  bool
+}
+
+type checker struct {
+	pass    *analysis.Pass
+	schemas map[types.Object]*schema
+	// dependsOn holds the DependsOn calls of relations, with the schema the relation is added to.
+	dependsOn map[*ast.CallExpr]types.Object
+	// presets holds the AddPreset and SetDefault calls, with the schema the selection is added to.
+	presets map[*ast.CallExpr]types.Object
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	c := &checker{
+		pass:      pass,
+		schemas:   map[types.Object]*schema{},
+		dependsOn: map[*ast.CallExpr]types.Object{},
+		presets:   map[*ast.CallExpr]types.Object{},
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Find the schema variables first, so fields added in any order or in init functions are known.
+	inspect.Preorder([]ast.Node{(*ast.ValueSpec)(nil)}, func(node ast.Node) {
+		spec := node.(*ast.ValueSpec)
+		for ix, name := range spec.Names {
+			if ix >= len(spec.Values) {
+				break
+			}
+			obj := pass.TypesInfo.Defs[name]
+			if obj == nil || obj.Parent() != pass.Pkg.Scope() {
+				continue
+			}
+			calls, root := c.methodChain(spec.Values[ix])
+			if s := c.newSchema(name.Name, root); s != nil {
+				c.schemas[obj] = s
+				c.apply(obj, calls)
+			}
+		}
+	})
+
+	// Fields and relations added to schema variables in statements, such as in an init function.
+	inspect.Preorder([]ast.Node{(*ast.ExprStmt)(nil)}, func(node ast.Node) {
+		calls, root := c.methodChain(node.(*ast.ExprStmt).X)
+		if obj := c.object(root); obj != nil && c.schemas[obj] != nil {
+			c.apply(obj, calls)
+		}
+	})
+
+	for obj, s := range c.schemas {
+		fact := &SchemaFact{Relations: map[string]SchemaRef{}, Complete: s.complete}
+		for field := range s.fields {
+			fact.Fields = append(fact.Fields, field)
+		}
+		for preset := range s.presets {
+			fact.Presets = append(fact.Presets, preset)
+		}
+		for name, child := range s.relations {
+			ref := SchemaRef{}
+			if child != nil {
+				ref = SchemaRef{Pkg: child.Pkg().Path(), Name: child.Name()}
+			}
+			fact.Relations[name] = ref
+		}
+		pass.ExportObjectFact(obj, fact)
+	}
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != alacartePath {
+			return
+		}
+
+		var owner types.Object
+		args := call.Args
+		switch {
+		case isMethod(fn, "ModelSchema", "Query"):
+			owner = c.object(call.Fun.(*ast.SelectorExpr).X)
+		case isMethod(fn, "ModelQuery", "Select"):
+			owner = c.queryOwner(call.Fun.(*ast.SelectorExpr).X)
+		case fn.Name() == "DependsOn" && recv(fn) == "":
+			owner = c.dependsOn[call]
+		case isMethod(fn, "ModelSchema", "AddPreset") && len(args) > 0:
+			owner, args = c.presets[call], args[1:]
+		case isMethod(fn, "ModelSchema", "SetDefault"):
+			owner = c.presets[call]
+		}
+		if owner == nil || call.Ellipsis.IsValid() {
+			return
+		}
+
+		for _, arg := range args {
+			value := pass.TypesInfo.Types[arg].Value
+			if value == nil || value.Kind() != constant.String {
+				continue
+			}
+			if msg := c.check(owner, constant.StringVal(value)); msg != "" {
+				pass.Reportf(arg.Pos(), "%s", msg)
+			}
+		}
+	})
+
+	return nil, nil
+}
+
+// methodCall is a call in a chain such as New(...).AddField(...).AddRelation(...).
+type methodCall struct {
+	name string
+	call *ast.CallExpr
+}
+
+// methodChain returns the method calls on the root expression, in the order they are called.
+func (c *checker) methodChain(expr ast.Expr) ([]methodCall, ast.Expr) {
+	var calls []methodCall
+	for {
+		call, ok := ast.Unparen(expr).(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || c.isPackage(selector.X) {
+			break
+		}
+		calls = append([]methodCall{{name: selector.Sel.Name, call: call}}, calls...)
+		expr = selector.X
+	}
+
+	return calls, ast.Unparen(expr)
+}
+
+func (c *checker) isPackage(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = c.pass.TypesInfo.Uses[ident].(*types.PkgName)
+	return ok
+}
+
+// newSchema returns a schema if expr calls alacarte.New or alacarte.FromStruct.
+func (c *checker) newSchema(name string, expr ast.Expr) *schema {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != alacartePath || recv(fn) != "" {
+		return nil
+	}
+
+	s := &schema{
+		name:      name,
+		fields:    map[string]bool{},
+		relations: map[string]types.Object{},
+		presets:   map[string]bool{},
+		complete:  true,
+	}
+	switch fn.Name() {
+	case "New":
+	case "FromStruct":
+		if model := schemaModel(c.pass.TypesInfo.TypeOf(call)); model != nil {
+			structFields(model, s.fields)
+		}
+	default:
+		return nil
+	}
+
+	return s
+}
+
+// apply adds the fields and relations of the method calls to the schema of obj.
+func (c *checker) apply(obj types.Object, calls []methodCall) {
+	s := c.schemas[obj]
+	for _, method := range calls {
+		fn, ok := typeutil.Callee(c.pass.TypesInfo, method.call).(*types.Func)
+		if !ok || recv(fn) != "ModelSchema" || len(method.call.Args) == 0 {
+			continue
+		}
+
+		switch method.name {
+		case "SetDefault":
+			c.presets[method.call] = obj
+			continue
+		case "AddField", "AddFieldType", "AddSimpleField", "AddRelation", "AddPreset":
+		default:
+			continue
+		}
+
+		value := c.pass.TypesInfo.Types[method.call.Args[0]].Value
+		if value == nil || value.Kind() != constant.String {
+			s.complete = false
+			continue
+		}
+		name := constant.StringVal(value)
+
+		switch method.name {
+		case "AddPreset":
+			s.presets[name] = true
+			c.presets[method.call] = obj
+			continue
+		case "AddRelation":
+		default:
+			s.fields[name] = true
+			continue
+		}
+		if len(method.call.Args) < 2 {
+			continue
+		}
+		s.relations[name] = c.relationChild(method.call.Args[1])
+
+		// DependsOn refers to the fields of the schema the relation is added to
+		ast.Inspect(method.call.Args[1], func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok {
+				c.dependsOn[call] = obj
+			}
+			return true
+		})
+	}
+}
+
+// relationChild returns the child schema variable of a relation, e.g. BookSchema in HasMany(BookSchema, ...).Keys(...).
+func (c *checker) relationChild(expr ast.Expr) types.Object {
+	_, root := c.methodChain(expr)
+	call, ok := root.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil
+	}
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != alacartePath {
+		return nil
+	}
+
+	return c.object(call.Args[0])
+}
+
+// queryOwner returns the schema variable a query is created from, e.g. AuthorSchema in AuthorSchema.Query().Where().
+func (c *checker) queryOwner(expr ast.Expr) types.Object {
+	calls, root := c.methodChain(expr)
+	if len(calls) == 0 || calls[0].name != "Query" {
+		return nil
+	}
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, calls[0].call).(*types.Func)
+	if !ok || !isMethod(fn, "ModelSchema", "Query") {
+		return nil
+	}
+
+	return c.object(root)
+}
+
+// object returns the package-level variable expr refers to, e.g. AuthorSchema or models.AuthorSchema.
+func (c *checker) object(expr ast.Expr) types.Object {
+	var ident *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return nil
+	}
+
+	obj, ok := c.pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return nil
+	}
+
+	return obj
+}
+
+// lookup returns the schema of a schema variable, from this package or from the facts of an imported package.
+func (c *checker) lookup(obj types.Object) *schema {
+	if obj == nil {
+		return nil
+	}
+	if s, ok := c.schemas[obj]; ok {
+		return s
+	}
+
+	var fact SchemaFact
+	if !c.pass.ImportObjectFact(obj, &fact) {
+		return nil
+	}
+
+	s := &schema{
+		name:      obj.Name(),
+		fields:    map[string]bool{},
+		relations: map[string]types.Object{},
+		presets:   map[string]bool{},
+		complete:  fact.Complete,
+	}
+	for _, field := range fact.Fields {
+		s.fields[field] = true
+	}
+	for _, preset := range fact.Presets {
+		s.presets[preset] = true
+	}
+	for name, ref := range fact.Relations {
+		s.relations[name] = c.resolve(ref)
+	}
+	c.schemas[obj] = s
+
+	return s
+}
+
+// resolve finds the variable of a reference in the imports of the package.
+func (c *checker) resolve(ref SchemaRef) types.Object {
+	if ref.Pkg == "" {
+		return nil
+	}
+
+	seen := map[*types.Package]bool{}
+	var find func(pkg *types.Package) types.Object
+	find = func(pkg *types.Package) types.Object {
+		if seen[pkg] {
+			return nil
+		}
+		seen[pkg] = true
+		if pkg.Path() == ref.Pkg {
+			return pkg.Scope().Lookup(ref.Name)
+		}
+		for _, imported := range pkg.Imports() {
+			if obj := find(imported); obj != nil {
+				return obj
+			}
+		}
+		return nil
+	}
+
+	return find(c.pass.Pkg)
+}
+
+// check returns a message if the path does not resolve on the schema of obj.
+func (c *checker) check(obj types.Object, path string) string {
+	full := path
+	// Exclusions such as "-books.content" refer to the same fields
+	path = strings.TrimPrefix(path, "-")
+	for s := c.lookup(obj); s != nil && s.complete; {
+		name, rest := splitPath(path)
+		if name == "" || name == "*" {
+			return ""
+		}
+
+		if preset, ok := strings.CutPrefix(name, "@"); ok {
+			if !s.presets[preset] {
+				return fmt.Sprintf("%q: %s is not a preset of %s", full, name, s.name)
+			}
+			return ""
+		}
+
+		if child, ok := s.relations[name]; ok {
+			if rest == "" || rest == "*" {
+				return ""
+			}
+			s, path = c.lookup(child), rest
+			continue
+		}
+
+		if s.fields[name] {
+			if rest != "" || strings.Contains(path[:len(path)-len(rest)], "(") {
+				return fmt.Sprintf("%q: %s of %s is a field, not a relation", full, name, s.name)
+			}
+			return ""
+		}
+
+		return fmt.Sprintf("%q: %s is not a field or relation of %s", full, name, s.name)
+	}
+
+	return ""
+}
+
+// splitPath splits the first name of a path, without its arguments, from the rest of the path.
+func splitPath(path string) (string, string) {
+	depth := 0
+	for ix, char := range path {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '.':
+			if depth == 0 {
+				name, _, _ := strings.Cut(path[:ix], "(")
+				return name, path[ix+1:]
+			}
+		}
+	}
+
+	name, _, _ := strings.Cut(path, "(")
+	return name, ""
+}
+
+// recv returns the name of the receiver type of a method, or an empty string for functions.
+func recv(fn *types.Func) string {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return ""
+	}
+
+	typ := sig.Recv().Type()
+	if pointer, ok := typ.(*types.Pointer); ok {
+		typ = pointer.Elem()
+	}
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+
+	return ""
+}
+
+func isMethod(fn *types.Func, receiver, name string) bool {
+	return fn.Name() == name && recv(fn) == receiver
+}
+
+// schemaModel returns the model type T of a *ModelSchema[T].
+func schemaModel(typ types.Type) types.Type {
+	if pointer, ok := typ.(*types.Pointer); ok {
+		typ = pointer.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || named.TypeArgs().Len() != 1 {
+		return nil
+	}
+
+	return named.TypeArgs().At(0)
+}
+
+// structFields adds the fields FromStruct creates for the `db` tags of the struct type.
+func structFields(typ types.Type, fields map[string]bool) {
+	structType, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+
+	for ix := range structType.NumFields() {
+		field := structType.Field(ix)
+		tag, tagged := reflect.StructTag(structType.Tag(ix)).Lookup("db")
+		if !tagged && field.Embedded() {
+			structFields(field.Type(), fields)
+			continue
+		}
+		if !tagged || tag == "-" || !field.Exported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = naming.SnakeCase(field.Name())
+		}
+		fields[name] = true
+	}
+}
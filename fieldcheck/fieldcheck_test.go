@@ -0,0 +1,12 @@
+package fieldcheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"pollex.nl/alacarte/fieldcheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), fieldcheck.Analyzer, "models", "queries")
+}
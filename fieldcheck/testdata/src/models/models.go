@@ -0,0 +1,63 @@
+package models
+
+import "pollex.nl/alacarte"
+
+type Author struct {
+	ID    uint64
+	Name  string
+	Books []Book
+}
+
+type Book struct {
+	ID       uint64 `db:"id"`
+	Title    string `db:"title"`
+	AuthorID uint64 `db:""`
+	Notes    string `db:"-"`
+	Author   *Author
+}
+
+const nameField = "name"
+
+var AuthorSchema = alacarte.New[Author]("authors"). // want AuthorSchema:"schema\\(2 fields, 1 relations\\)"
+							AddSimpleField("id", func(t *Author) any { return &t.ID }).
+							AddField(nameField, alacarte.Col("name")).
+							AddRelation("books",
+		alacarte.HasMany[Author, Book](BookSchema, alacarte.DependsOn("id", "books.author_id", "boks.author_id")), // want `"boks.author_id": boks is not a field or relation of AuthorSchema`
+	)
+
+var BookSchema = alacarte.FromStruct[Book]("books") // want BookSchema:"schema\\(3 fields, 1 relations\\)"
+
+func init() {
+	BookSchema.AddRelation("author", alacarte.BelongsTo[Book](AuthorSchema).Keys("author_id", "id"))
+	AuthorSchema.AddPreset("summary", "id", "name", "-books.title").AddPreset("detail", "@summary", "nam") // want `"nam": nam is not a field or relation of AuthorSchema`
+	BookSchema.SetDefault("id", "title", "author.@summary", "author.@full")                                // want `"author.@full": @full is not a preset of AuthorSchema`
+}
+
+var dynamic = "name"
+
+// OpenSchema has a field name that is not constant, so it is not checked.
+var OpenSchema = alacarte.New[Author]("authors"). // want OpenSchema:"schema\\(0 fields, 0 relations\\)"
+							AddSimpleField(dynamic, func(t *Author) any { return &t.Name })
+
+func List() {
+	AuthorSchema.Query("id", "name", "books.title", "books(limit:3,order:-id).author.name", "*", "books.*")
+	AuthorSchema.Query("title")                                        // want `"title": title is not a field or relation of AuthorSchema`
+	AuthorSchema.Query("books.notes")                                  // want `"books.notes": notes is not a field or relation of BookSchema`
+	AuthorSchema.Query("name.first")                                   // want `"name.first": name of AuthorSchema is a field, not a relation`
+	AuthorSchema.Query("id").OrderBy("-id").Select("books.author.nme") // want `"books.author.nme": nme is not a field or relation of AuthorSchema`
+	OpenSchema.Query("anything")
+	AuthorSchema.Query("*", "-name", "-books.author_id", "@summary", "books.author.@detail")
+	AuthorSchema.Query("-nme")         // want `"-nme": nme is not a field or relation of AuthorSchema`
+	AuthorSchema.Query("books.@cards") // want `"books.@cards": @cards is not a preset of BookSchema`
+
+	fields := []string{"unknown"}
+	AuthorSchema.Query(fields...)
+}
+
+var GenreSchema = alacarte.New[Author]("genres"). // want GenreSchema:"schema\\(1 fields, 1 relations\\)"
+							AddSimpleField("name", func(t *Author) any { return &t.Name }).
+							AddRelation("books", alacarte.HasManyByKey(BookSchema, func(a Author) uint64 { return a.ID }, alacarte.DependsOn("nam"))) // want `"nam": nam is not a field or relation of GenreSchema`
+
+func ListGenres() {
+	GenreSchema.Query("books.titel") // want `"books.titel": titel is not a field or relation of BookSchema`
+}
@@ -0,0 +1,48 @@
+// Package alacarte is a stub of the alacarte API used by the analyzer.
+package alacarte
+
+type QueryMod func()
+
+type ModelSchema[T any] struct{}
+
+type ModelQuery[T any] struct{}
+
+type Relation[M any] struct{}
+
+func New[T any](table string) *ModelSchema[T] { return nil }
+
+func FromStruct[T any](table string) *ModelSchema[T] { return nil }
+
+func (schema *ModelSchema[T]) AddSimpleField(name string, ptr func(t *T) any) *ModelSchema[T] {
+	return schema
+}
+
+func (schema *ModelSchema[T]) AddField(name string, mod QueryMod) *ModelSchema[T] { return schema }
+
+func (schema *ModelSchema[T]) AddRelation(name string, relation Relation[T]) *ModelSchema[T] {
+	return schema
+}
+
+func (schema *ModelSchema[T]) AddPreset(name string, fields ...string) *ModelSchema[T] { return schema }
+
+func (schema *ModelSchema[T]) SetDefault(fields ...string) *ModelSchema[T] { return schema }
+
+func (schema *ModelSchema[T]) Query(fields ...string) ModelQuery[T] { return ModelQuery[T]{} }
+
+func (model ModelQuery[T]) Select(fields ...string) ModelQuery[T] { return model }
+
+func (model ModelQuery[T]) OrderBy(keys ...string) ModelQuery[T] { return model }
+
+func (relation Relation[M]) Keys(parentKey, childKey string) Relation[M] { return relation }
+
+func HasMany[M, N any](child *ModelSchema[N], depends []string) Relation[M] { return Relation[M]{} }
+
+func BelongsTo[M, N any](owner *ModelSchema[N]) Relation[M] { return Relation[M]{} }
+
+func DependsOn(fields ...string) []string { return fields }
+
+func Col(names ...string) QueryMod { return nil }
+
+func HasManyByKey[M, N any](child *ModelSchema[N], parentKey func(M) uint64, depends []string) Relation[M] {
+	return Relation[M]{}
+}
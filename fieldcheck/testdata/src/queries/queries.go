@@ -0,0 +1,9 @@
+package queries
+
+import "models"
+
+func List() {
+	models.AuthorSchema.Query("id", "books.author_id", "@summary")
+	models.AuthorSchema.Query("books.isbn") // want `"books.isbn": isbn is not a field or relation of BookSchema`
+	models.AuthorSchema.Query("@full")      // want `"@full": @full is not a preset of AuthorSchema`
+}
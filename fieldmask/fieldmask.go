@@ -0,0 +1,71 @@
+// Package fieldmask converts between the paths of a google.protobuf.FieldMask and alacarte selections, so gRPC servers
+// can honour read masks. It does not depend on protobuf, *fieldmaskpb.FieldMask satisfies Mask:
+//
+//	query, err := fieldmask.Query(AuthorSchema, req.GetReadMask())
+//	if err != nil {
+//		return nil, status.Error(codes.InvalidArgument, err.Error())
+//	}
+//	authors, err := query.Collect(ctx, db)
+//	mask := &fieldmaskpb.FieldMask{Paths: fieldmask.Paths(query.Selection())}
+package fieldmask
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"pollex.nl/alacarte"
+)
+
+// Mask is a field mask, such as *fieldmaskpb.FieldMask.
+type Mask interface {
+	GetPaths() []string
+}
+
+// Query creates a query on the schema that selects the paths of the mask. See FromPaths.
+func Query[T any](schema *alacarte.ModelSchema[T], mask Mask) (alacarte.ModelQuery[T], error) {
+	if mask == nil {
+		return FromPaths(schema)
+	}
+
+	return FromPaths(schema, mask.GetPaths()...)
+}
+
+// FromPaths creates a query on the schema that selects the paths, such as "name" and "books.name". A relation path
+// such as "books" selects all fields of the relation, and without paths all fields of the schema are selected. All
+// invalid paths are returned together, each wrapping the error of Check.
+func FromPaths[T any](schema *alacarte.ModelSchema[T], paths ...string) (alacarte.ModelQuery[T], error) {
+	if len(paths) == 0 {
+		return schema.Query("*"), nil
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if err := schema.Check(path); err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return alacarte.ModelQuery[T]{}, errors.Join(errs...)
+	}
+
+	return schema.Query(paths...), nil
+}
+
+// Paths returns the paths of the fields in the selection, as a field mask of what a query loads. Relations are only
+// listed by themselves if none of their fields are selected, as a relation path covers all its fields.
+func Paths(selection alacarte.Selection) []string {
+	paths := append([]string{}, selection.Fields...)
+	for _, name := range slices.Sorted(maps.Keys(selection.Relations)) {
+		children := Paths(selection.Relations[name])
+		if len(children) == 0 {
+			paths = append(paths, name)
+		}
+		for _, child := range children {
+			paths = append(paths, name+"."+child)
+		}
+	}
+
+	return paths
+}
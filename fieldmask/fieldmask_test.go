@@ -0,0 +1,78 @@
+package fieldmask_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/example"
+	"pollex.nl/alacarte/fieldmask"
+)
+
+// mask is a stand-in for *fieldmaskpb.FieldMask.
+type mask struct {
+	Paths []string
+}
+
+func (m *mask) GetPaths() []string {
+	if m == nil {
+		return nil
+	}
+	return m.Paths
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		mask   fieldmask.Mask
+		loaded []string
+	}{
+		{
+			name:   "fields and relation fields",
+			mask:   &mask{Paths: []string{"name", "books.name", "books.genre.name"}},
+			loaded: []string{"name", "books.name", "books.genre.name"},
+		},
+		{
+			name:   "relation",
+			mask:   &mask{Paths: []string{"id", "books"}},
+			loaded: []string{"id", "books.author_id", "books.genre_id", "books.id", "books.name"},
+		},
+		{
+			name:   "empty mask",
+			mask:   &mask{},
+			loaded: []string{"id", "name"},
+		},
+		{
+			name:   "nil mask",
+			mask:   (*mask)(nil),
+			loaded: []string{"id", "name"},
+		},
+		{
+			name:   "no mask",
+			loaded: []string{"id", "name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			query, err := fieldmask.Query(example.AuthorSchema, test.mask)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, test.loaded, fieldmask.Paths(query.Selection()))
+		})
+	}
+}
+
+func TestFromPathsInvalid(t *testing.T) {
+	// Act
+	_, err := fieldmask.FromPaths(example.AuthorSchema, "name", "age", "books.title")
+
+	// Assert
+	require.ErrorIs(t, err, alacarte.ErrNoSuchField)
+	assert.ErrorContains(t, err, `"age": `)
+	assert.ErrorContains(t, err, `"books.title": `)
+	assert.NotContains(t, err.Error(), `"name"`)
+}
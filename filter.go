@@ -0,0 +1,246 @@
+package alacarte
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type filterOp int
+
+const (
+	opEq filterOp = iota
+	opNotEq
+	opIn
+	opLt
+	opLtOrEq
+	opGt
+	opGtOrEq
+	opLike
+	opLikeText
+	opIsNull
+	opAnd
+	opOr
+	opNot
+)
+
+// Filter is a filter expression that references schema field names instead of columns. Use it with ModelQuery.Where.
+type Filter struct {
+	op      filterOp
+	field   string
+	value   any
+	filters []Filter
+}
+
+func Eq(field string, value any) Filter {
+	return Filter{op: opEq, field: field, value: value}
+}
+
+func NotEq(field string, value any) Filter {
+	return Filter{op: opNotEq, field: field, value: value}
+}
+
+func In[V any](field string, values ...V) Filter {
+	return Filter{op: opIn, field: field, value: values}
+}
+
+func Lt(field string, value any) Filter {
+	return Filter{op: opLt, field: field, value: value}
+}
+
+func LtOrEq(field string, value any) Filter {
+	return Filter{op: opLtOrEq, field: field, value: value}
+}
+
+func Gt(field string, value any) Filter {
+	return Filter{op: opGt, field: field, value: value}
+}
+
+func GtOrEq(field string, value any) Filter {
+	return Filter{op: opGtOrEq, field: field, value: value}
+}
+
+func Like(field string, pattern string) Filter {
+	return Filter{op: opLike, field: field, value: pattern}
+}
+
+// Contains matches fields that contain the text. Unlike Like, % and _ in the text only match themselves.
+func Contains(field, text string) Filter {
+	return Filter{op: opLikeText, field: field, value: "%" + escapeLike(text) + "%"}
+}
+
+// StartsWith matches fields that start with the text, see Contains.
+func StartsWith(field, text string) Filter {
+	return Filter{op: opLikeText, field: field, value: escapeLike(text) + "%"}
+}
+
+// EndsWith matches fields that end with the text, see Contains.
+func EndsWith(field, text string) Filter {
+	return Filter{op: opLikeText, field: field, value: "%" + escapeLike(text)}
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with a backslash.
+var escapeLike = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace
+
+func IsNull(field string) Filter {
+	return Filter{op: opIsNull, field: field}
+}
+
+func And(filters ...Filter) Filter {
+	return Filter{op: opAnd, filters: filters}
+}
+
+func Or(filters ...Filter) Filter {
+	return Filter{op: opOr, filters: filters}
+}
+
+func Not(filter Filter) Filter {
+	return Filter{op: opNot, filters: []Filter{filter}}
+}
+
+// fields returns every field path referenced by the filter.
+func (filter Filter) fields() []string {
+	if filter.field != "" {
+		return []string{filter.field}
+	}
+
+	var fields []string
+	for _, child := range filter.filters {
+		fields = append(fields, child.fields()...)
+	}
+
+	return fields
+}
+
+// trimRelation strips the relation prefix from all field paths, making the filter apply to the relation's schema.
+func (filter Filter) trimRelation() Filter {
+	if filter.field != "" {
+		_, filter.field = isNested(filter.field)
+		return filter
+	}
+
+	children := make([]Filter, len(filter.filters))
+	for ix, child := range filter.filters {
+		children[ix] = child.trimRelation()
+	}
+	filter.filters = children
+
+	return filter
+}
+
+// filterRelation returns the relation the filter applies to, or an empty string if it applies to the schema itself.
+// A filter can not mix fields from different relations.
+func (schema *ModelSchema[T]) filterRelation(filter Filter) (string, error) {
+	var (
+		relation string
+		scoped   bool
+	)
+
+	for _, path := range filter.fields() {
+		field, rest := isNested(path)
+
+		current := ""
+		if rest != "" {
+			if !schema.hasRelation(field) {
+				return "", fmt.Errorf("%w: %s", ErrNoSuchRelation, field)
+			}
+			if err := schema.Relations[field].Check(rest); err != nil {
+				return "", err
+			}
+			current = field
+		} else if !schema.hasField(field) {
+			return "", fmt.Errorf("%w: %s", ErrNoSuchField, field)
+		} else if schema.Fields[field].Unfilterable {
+			return "", fmt.Errorf("%w: %s", ErrNotFilterable, field)
+		}
+
+		if scoped && current != relation {
+			return "", fmt.Errorf("%w: filter mixes fields of %q and %q", ErrInvalidFilter, relation, current)
+		}
+		relation, scoped = current, true
+	}
+
+	return relation, nil
+}
+
+// filterSql translates the filter to a squirrel condition on the columns of the schema fields.
+func (schema *ModelSchema[T]) filterSql(filter Filter, table string) (squirrel.Sqlizer, error) {
+	switch filter.op {
+	case opAnd, opOr:
+		parts := make([]squirrel.Sqlizer, 0, len(filter.filters))
+		for _, child := range filter.filters {
+			part, err := schema.filterSql(child, table)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		if filter.op == opAnd {
+			return squirrel.And(parts), nil
+		}
+		return squirrel.Or(parts), nil
+	case opNot:
+		part, err := schema.filterSql(filter.filters[0], table)
+		if err != nil {
+			return nil, err
+		}
+		query, args, err := part.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		return squirrel.Expr("NOT ("+query+")", args...), nil
+	}
+
+	col, err := schema.fieldColumn(filter.field, table)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filter.op {
+	case opEq, opIn:
+		return squirrel.Eq{col: filter.value}, nil
+	case opNotEq:
+		return squirrel.NotEq{col: filter.value}, nil
+	case opLt:
+		return squirrel.Lt{col: filter.value}, nil
+	case opLtOrEq:
+		return squirrel.LtOrEq{col: filter.value}, nil
+	case opGt:
+		return squirrel.Gt{col: filter.value}, nil
+	case opGtOrEq:
+		return squirrel.GtOrEq{col: filter.value}, nil
+	case opLike:
+		return squirrel.Like{col: filter.value}, nil
+	case opLikeText:
+		return squirrel.Expr(col+` LIKE ? ESCAPE '\'`, filter.value), nil
+	case opIsNull:
+		return squirrel.Eq{col: nil}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown operator", ErrInvalidFilter)
+}
+
+// fieldColumn returns the column expression the field's QueryMod selects. Fields that select more than one column,
+// or a column with arguments, can not be used in expressions.
+func (schema *ModelSchema[T]) fieldColumn(name, table string) (string, error) {
+	field, ok := schema.Fields[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoSuchField, name)
+	}
+
+	columns := columnsOf(field.Mod, table)
+	if len(columns) != 1 {
+		return "", fmt.Errorf("%w: %s", ErrNotSingleColumn, name)
+	}
+
+	col, args, err := columns[0].ToSql()
+	if err != nil {
+		return "", err
+	}
+	if len(args) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrNotSingleColumn, name)
+	}
+
+	return col, nil
+}
@@ -0,0 +1,117 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestWhere(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").
+		Values(1, "Jeff", "cool,awesome").
+		Values(2, "Madonna", "vocal").
+		Values(3, "Prince", "purple").Exec()
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).
+		Values(3, "Sing baby sing", 2).Exec()
+
+	t.Run("eq", func(t *testing.T) {
+		authors, err := author.Query("id", "name").
+			Where(alacarte.Eq("name", "Madonna")).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 1)
+		assert.Equal(t, uint64(2), authors[0].ID)
+	})
+
+	t.Run("in", func(t *testing.T) {
+		authors, err := author.Query("id").
+			Where(alacarte.In("id", 1, 3)).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		assert.Len(t, authors, 2)
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		authors, err := author.Query("id").
+			Where(alacarte.Or(
+				alacarte.And(alacarte.Gt("id", 1), alacarte.Like("name", "M%")),
+				alacarte.Not(alacarte.NotEq("id", 3)),
+			)).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 2)
+		assert.ElementsMatch(t, []uint64{2, 3}, []uint64{authors[0].ID, authors[1].ID})
+	})
+
+	t.Run("relation field filters children", func(t *testing.T) {
+		authors, err := author.Query("id", "books.name").
+			Where(alacarte.Eq("id", 1), alacarte.Like("books.name", "Cooking%")).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 1)
+		require.Len(t, authors[0].Books, 1)
+		assert.Equal(t, "Cooking like Jeff", authors[0].Books[0].Name)
+	})
+
+	t.Run("relation field on unselected relation", func(t *testing.T) {
+		query := author.Query("id").Where(alacarte.Eq("books.name", "Cooking like Jeff"))
+		assert.ErrorIs(t, query.Err(), alacarte.ErrInvalidFilter)
+
+		_, err := query.Collect(context.Background(), db)
+		assert.ErrorIs(t, err, alacarte.ErrInvalidFilter)
+
+		query = author.Query("id", "books.name").Where(alacarte.Eq("books.name", "Cooking like Jeff")).Select("-books")
+		assert.ErrorIs(t, query.Err(), alacarte.ErrInvalidFilter)
+	})
+
+	t.Run("text matching", func(t *testing.T) {
+		sq.Insert("authors").Values(4, "J_x", "").Values(5, `50% \o/`, "").Exec()
+		t.Cleanup(func() { sq.Delete("authors").Where("id > 3").Exec() })
+
+		tests := []struct {
+			filter alacarte.Filter
+			ids    []uint64
+		}{
+			{alacarte.Contains("name", "_"), []uint64{4}},
+			{alacarte.Contains("name", "%"), []uint64{5}},
+			{alacarte.StartsWith("name", "J"), []uint64{1, 4}},
+			{alacarte.EndsWith("name", `\o/`), []uint64{5}},
+			{alacarte.Not(alacarte.Contains("name", "e")), []uint64{2, 4, 5}},
+		}
+		for _, test := range tests {
+			authors, err := author.Query("id").Where(test.filter).OrderBy("id").Collect(context.Background(), db)
+			require.NoError(t, err)
+
+			var ids []uint64
+			for _, author := range authors {
+				ids = append(ids, author.ID)
+			}
+			assert.Equal(t, test.ids, ids)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		query := author.Query("id").Where(alacarte.Eq("age", 12))
+		assert.ErrorIs(t, query.Err(), alacarte.ErrNoSuchField)
+
+		query = author.Query("id").Where(alacarte.IsNull("books.isbn"))
+		assert.ErrorIs(t, query.Err(), alacarte.ErrNoSuchField)
+	})
+
+	t.Run("mixed relations", func(t *testing.T) {
+		query := author.Query("id").Where(alacarte.Or(alacarte.Eq("id", 1), alacarte.Eq("books.id", 1)))
+		assert.ErrorIs(t, query.Err(), alacarte.ErrInvalidFilter)
+	})
+}
@@ -4,16 +4,19 @@ go 1.24.4
 
 require (
 	github.com/Masterminds/squirrel v1.5.4
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0
 	github.com/mattn/go-sqlite3 v1.14.28
 	github.com/samber/lo v1.51.0
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/sync v0.17.0
+	golang.org/x/tools v0.38.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0 // indirect
 	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.29.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
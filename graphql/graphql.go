@@ -0,0 +1,357 @@
+// Package graphql creates alacarte queries from GraphQL selection sets. It does not depend on a GraphQL library, the
+// selection set of a resolver is converted to Fields, for example from the result of gqlgen's CollectFields:
+//
+//	query {
+//	  authors(first: 10, orderBy: {name: ASC}) {
+//	    name
+//	    books(first: 3, where: {name: {like: "%Jeff%"}}) { name }
+//	  }
+//	}
+//
+// becomes AuthorSchema.Query("name", "books(limit:3).name").Limit(10).OrderBy("name") with a filter on the books, so
+// the request is resolved with one batched query per level of relations.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/internal/naming"
+)
+
+// Field is a field of a selection set. Fragments are expected to be flattened, as CollectFields does.
+type Field struct {
+	// Name is the name of the field in the GraphQL schema, not its alias.
+	Name string
+	// Arguments are the argument values with variables resolved. Input objects are map[string]any and lists []any.
+	Arguments map[string]any
+	// Selections are the fields selected on a relation.
+	Selections []Field
+}
+
+// Options configure the translation of fields and arguments.
+type Options struct {
+	// Names maps GraphQL field names to schema field names. Names that are not mapped are converted from camel case to
+	// snake case, e.g. authorId to author_id.
+	Names map[string]string
+	// First, OrderBy and Where are the names of the arguments that limit, order and filter a list.
+	First   string
+	OrderBy string
+	Where   string
+}
+
+// DefaultOptions are the options used by Query.
+var DefaultOptions = Options{First: "first", OrderBy: "orderBy", Where: "where"}
+
+// Query creates a query on the schema for a root field, using DefaultOptions. See QueryWith.
+func Query[T any](schema *alacarte.ModelSchema[T], field Field) (alacarte.ModelQuery[T], error) {
+	return QueryWith(schema, field, DefaultOptions)
+}
+
+// QueryWith creates a query on the schema for a root field, such as authors in `{ authors(first: 10) { name } }`.
+// The selections of the field are selected, and the arguments of the field and of its relations limit, order and
+// filter the models of that level:
+//
+//   - first limits the number of models, or the number of children per parent.
+//   - orderBy is a field, a list of fields prefixed with "-" to sort descending, or objects such as {name: DESC}.
+//   - where is an object of fields with a value to compare with or an object of operators, e.g.
+//     {name: {like: "J%"}, id: {in: [1, 2]}}. The operators are eq, neq, in, lt, lte, gt, gte, like and isNull. The
+//     keys AND, OR and NOT combine filters.
+//
+// Other arguments are ignored, they are left to the resolvers. Errors of the arguments and the selection are returned
+// together.
+func QueryWith[T any](
+	schema *alacarte.ModelSchema[T],
+	field Field,
+	options Options,
+) (alacarte.ModelQuery[T], error) {
+	b := builder{options: options}
+	b.selections("", field.Selections)
+
+	if len(b.paths) == 0 {
+		b.paths = append(b.paths, "*")
+	}
+	query := schema.Query(b.withArgs()...)
+
+	if first, ok := field.Arguments[options.First]; ok {
+		limit, err := b.limit(first)
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("%s: %w", field.Name, err))
+		} else {
+			query = query.Limit(limit)
+		}
+	}
+	query = query.OrderBy(b.orderBy(field.Name, field.Arguments[options.OrderBy])...)
+	query = query.Where(b.filters...)
+	if where, ok := field.Arguments[options.Where]; ok {
+		query = query.Where(b.where(field.Name, "", where)...)
+	}
+
+	return query, errors.Join(append(b.errs, query.Err())...)
+}
+
+// builder collects the paths and filters of a selection set.
+type builder struct {
+	options Options
+	paths   []string
+	// args holds the arguments of relations by their path, e.g. "limit:3" for "books"
+	args    map[string]string
+	filters []alacarte.Filter
+	errs    []error
+}
+
+// selections adds the paths of the fields selected on the relation at prefix.
+func (b *builder) selections(prefix string, fields []Field) {
+	for _, field := range fields {
+		if strings.HasPrefix(field.Name, "__") {
+			// Introspection fields such as __typename
+			continue
+		}
+
+		path := join(prefix, b.name(field.Name))
+		if len(field.Selections) == 0 {
+			b.paths = append(b.paths, path)
+			continue
+		}
+
+		b.relation(path, field)
+		before := len(b.paths)
+		b.selections(path, field.Selections)
+		if len(b.paths) == before {
+			// Only introspection fields were selected, select the relation itself
+			b.paths = append(b.paths, path)
+		}
+	}
+}
+
+// relation adds the limit, ordering and filters of the relation at path.
+func (b *builder) relation(path string, field Field) {
+	var args []string
+	if first, ok := field.Arguments[b.options.First]; ok {
+		limit, err := b.limit(first)
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("%s: %w", path, err))
+		} else {
+			args = append(args, fmt.Sprintf("limit:%d", limit))
+		}
+	}
+	for _, key := range b.orderBy(path, field.Arguments[b.options.OrderBy]) {
+		args = append(args, "order:"+key)
+	}
+	if where, ok := field.Arguments[b.options.Where]; ok {
+		b.filters = append(b.filters, b.where(path, path, where)...)
+	}
+
+	if len(args) > 0 {
+		if b.args == nil {
+			b.args = map[string]string{}
+		}
+		b.args[path] = strings.Join(args, ",")
+	}
+}
+
+// withArgs returns the paths with the arguments of every relation on the first path that selects it, e.g.
+// "books(limit:3).name" and "books.id".
+func (b *builder) withArgs() []string {
+	applied := map[string]bool{}
+	paths := make([]string, len(b.paths))
+	for ix, path := range b.paths {
+		names := strings.Split(path, ".")
+		for pos := range names {
+			relation := strings.Join(names[:pos+1], ".")
+			if args, ok := b.args[relation]; ok && !applied[relation] {
+				applied[relation] = true
+				names[pos] += "(" + args + ")"
+			}
+		}
+		paths[ix] = strings.Join(names, ".")
+	}
+
+	return paths
+}
+
+func (b *builder) limit(value any) (uint64, error) {
+	switch value := value.(type) {
+	case int:
+		if value > 0 {
+			return uint64(value), nil
+		}
+	case int32:
+		if value > 0 {
+			return uint64(value), nil
+		}
+	case int64:
+		if value > 0 {
+			return uint64(value), nil
+		}
+	case float64:
+		if value > 0 && value == float64(uint64(value)) {
+			return uint64(value), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s %v", alacarte.ErrInvalidArgument, b.options.First, value)
+}
+
+// orderBy returns the ordering keys of an orderBy argument, errors are reported on path.
+func (b *builder) orderBy(path string, value any) []string {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		desc := strings.HasPrefix(value, "-")
+		key := b.name(strings.TrimPrefix(value, "-"))
+		if desc {
+			return []string{"-" + key}
+		}
+		return []string{key}
+	case []string:
+		var keys []string
+		for _, item := range value {
+			keys = append(keys, b.orderBy(path, item)...)
+		}
+		return keys
+	case []any:
+		var keys []string
+		for _, item := range value {
+			keys = append(keys, b.orderBy(path, item)...)
+		}
+		return keys
+	case map[string]any:
+		var keys []string
+		for _, name := range sortedKeys(value) {
+			direction, _ := value[name].(string)
+			key := b.name(name)
+			switch strings.ToUpper(direction) {
+			case "ASC":
+				keys = append(keys, key)
+			case "DESC":
+				keys = append(keys, "-"+key)
+			default:
+				b.errs = append(b.errs,
+					fmt.Errorf("%s: %w: %s direction %v", path, alacarte.ErrInvalidArgument, b.options.OrderBy, value[name]))
+			}
+		}
+		return keys
+	}
+
+	b.errs = append(b.errs, fmt.Errorf("%s: %w: %s %v", path, alacarte.ErrInvalidArgument, b.options.OrderBy, value))
+
+	return nil
+}
+
+// where returns the filters of a where argument, on fields prefixed with prefix.
+func (b *builder) where(path, prefix string, value any) []alacarte.Filter {
+	object, ok := value.(map[string]any)
+	if !ok {
+		if value != nil {
+			b.errs = append(b.errs, fmt.Errorf("%s: %w: %s %v", path, alacarte.ErrInvalidArgument, b.options.Where, value))
+		}
+		return nil
+	}
+
+	var filters []alacarte.Filter
+	for _, key := range sortedKeys(object) {
+		switch key {
+		case "AND", "OR":
+			var operands []alacarte.Filter
+			for _, item := range list(object[key]) {
+				operands = append(operands, alacarte.And(b.where(path, prefix, item)...))
+			}
+			if key == "AND" {
+				filters = append(filters, alacarte.And(operands...))
+			} else {
+				filters = append(filters, alacarte.Or(operands...))
+			}
+		case "NOT":
+			filters = append(filters, alacarte.Not(alacarte.And(b.where(path, prefix, object[key])...)))
+		default:
+			filters = append(filters, b.compare(path, join(prefix, b.name(key)), object[key])...)
+		}
+	}
+
+	return filters
+}
+
+// compare returns the filters on a field, value is either compared with or an object of operators.
+func (b *builder) compare(path, field string, value any) []alacarte.Filter {
+	operators, ok := value.(map[string]any)
+	if !ok {
+		return []alacarte.Filter{alacarte.Eq(field, value)}
+	}
+
+	var filters []alacarte.Filter
+	for _, op := range sortedKeys(operators) {
+		operand := operators[op]
+		switch op {
+		case "eq":
+			filters = append(filters, alacarte.Eq(field, operand))
+		case "neq":
+			filters = append(filters, alacarte.NotEq(field, operand))
+		case "in":
+			filters = append(filters, alacarte.In(field, list(operand)...))
+		case "lt":
+			filters = append(filters, alacarte.Lt(field, operand))
+		case "lte":
+			filters = append(filters, alacarte.LtOrEq(field, operand))
+		case "gt":
+			filters = append(filters, alacarte.Gt(field, operand))
+		case "gte":
+			filters = append(filters, alacarte.GtOrEq(field, operand))
+		case "like":
+			pattern, ok := operand.(string)
+			if !ok {
+				b.errs = append(b.errs, fmt.Errorf("%s: %w: like %v on %s", path, alacarte.ErrInvalidArgument, operand, field))
+				continue
+			}
+			filters = append(filters, alacarte.Like(field, pattern))
+		case "isNull":
+			if isNull, _ := operand.(bool); isNull {
+				filters = append(filters, alacarte.IsNull(field))
+			} else {
+				filters = append(filters, alacarte.Not(alacarte.IsNull(field)))
+			}
+		default:
+			b.errs = append(b.errs, fmt.Errorf("%s: %w: operator %q on %s", path, alacarte.ErrInvalidArgument, op, field))
+		}
+	}
+
+	return filters
+}
+
+// name returns the schema field name of a GraphQL field name.
+func (b *builder) name(name string) string {
+	if mapped, ok := b.options.Names[name]; ok {
+		return mapped
+	}
+
+	return naming.SnakeCase(name)
+}
+
+func list(value any) []any {
+	if items, ok := value.([]any); ok {
+		return items
+	}
+
+	return []any{value}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
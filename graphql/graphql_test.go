@@ -0,0 +1,163 @@
+//nolint:errcheck
+package graphql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/example"
+	"pollex.nl/alacarte/graphql"
+)
+
+func TestQuery(t *testing.T) {
+	// Arrange
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		create table authors (id integer not null, name text not null);
+		create table books (id integer not null, name text not null, author_id integer, genre_id integer);
+		create table genres (id integer not null, name text not null);
+	`)
+	require.NoError(t, err)
+	sq := squirrel.StatementBuilder.RunWith(db)
+	sq.Insert("authors").Values(1, "Jeff").Values(2, "Mo").Exec()
+	sq.Insert("books").Values(1, "Life of Jeff", 1, 1).Values(2, "Cooking like Jeff", 1, 2).Values(3, "Mo", 2, 1).Exec()
+	sq.Insert("genres").Values(1, "Biography").Values(2, "Cooking").Exec()
+
+	// authors(orderBy: {name: DESC}) {
+	//   name
+	//   books(first: 1, orderBy: "-id", where: {name: {like: "%Jeff%"}}) { name genre { name } }
+	//   __typename
+	// }
+	field := graphql.Field{
+		Name:      "authors",
+		Arguments: map[string]any{"orderBy": map[string]any{"name": "DESC"}},
+		Selections: []graphql.Field{
+			{Name: "name"},
+			{
+				Name: "books",
+				Arguments: map[string]any{
+					"first":   1,
+					"orderBy": "-id",
+					"where":   map[string]any{"name": map[string]any{"like": "%Jeff%"}},
+				},
+				Selections: []graphql.Field{
+					{Name: "name"},
+					{Name: "genre", Selections: []graphql.Field{{Name: "name"}}},
+				},
+			},
+			{Name: "__typename"},
+		},
+	}
+
+	// Act
+	query, err := graphql.Query(example.AuthorSchema, field)
+	require.NoError(t, err)
+	authors, err := query.Collect(context.Background(), db)
+	require.NoError(t, err)
+
+	// Assert
+	data, err := alacarte.MarshalJSON(authors, query.Selection())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "Mo", "books": []},
+		{"name": "Jeff", "books": [{"name": "Cooking like Jeff", "genre": {"name": "Cooking"}}]}
+	]`, string(data))
+}
+
+func TestQueryWith(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   graphql.Field
+		options graphql.Options
+		paths   []string
+	}{
+		{
+			name:  "all fields without selections",
+			field: graphql.Field{Name: "books"},
+			paths: []string{"author_id", "genre_id", "id", "name"},
+		},
+		{
+			name:  "camel case names",
+			field: graphql.Field{Name: "books", Selections: []graphql.Field{{Name: "authorId"}, {Name: "genreId"}}},
+			paths: []string{"author_id", "genre_id"},
+		},
+		{
+			name: "mapped names and arguments",
+			field: graphql.Field{
+				Name: "books",
+				Selections: []graphql.Field{
+					{Name: "title"},
+					{
+						Name:       "writer",
+						Arguments:  map[string]any{"filter": map[string]any{"name": "Jeff"}},
+						Selections: []graphql.Field{{Name: "title"}},
+					},
+				},
+			},
+			options: graphql.Options{Names: map[string]string{"title": "name", "writer": "author"}, Where: "filter"},
+			paths:   []string{"name", "author", "author.name"},
+		},
+		{
+			name: "relation with only introspection fields",
+			field: graphql.Field{
+				Name:       "books",
+				Selections: []graphql.Field{{Name: "genre", Selections: []graphql.Field{{Name: "__typename"}}}},
+			},
+			paths: []string{"genre", "genre.id", "genre.name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Arrange
+			options := test.options
+			if options.First == "" {
+				options.First, options.OrderBy = graphql.DefaultOptions.First, graphql.DefaultOptions.OrderBy
+			}
+			if options.Where == "" {
+				options.Where = graphql.DefaultOptions.Where
+			}
+
+			// Act
+			query, err := graphql.QueryWith(example.BookSchema, test.field, options)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, test.paths, query.Selection().Paths())
+		})
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	// Arrange
+	field := graphql.Field{
+		Name:      "authors",
+		Arguments: map[string]any{"first": -1, "orderBy": map[string]any{"name": "UP"}},
+		Selections: []graphql.Field{
+			{Name: "age"},
+			{
+				Name:       "books",
+				Arguments:  map[string]any{"where": map[string]any{"name": map[string]any{"startsWith": "J"}}},
+				Selections: []graphql.Field{{Name: "name"}},
+			},
+		},
+	}
+
+	// Act
+	_, err := graphql.Query(example.AuthorSchema, field)
+
+	// Assert
+	require.ErrorIs(t, err, alacarte.ErrInvalidArgument)
+	require.ErrorIs(t, err, alacarte.ErrNoSuchField)
+	assert.ErrorContains(t, err, "authors: invalid relation argument: first -1")
+	assert.ErrorContains(t, err, "orderBy direction UP")
+	assert.ErrorContains(t, err, `books: invalid relation argument: operator "startsWith" on books.name`)
+}
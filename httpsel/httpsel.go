@@ -0,0 +1,260 @@
+// Package httpsel creates alacarte queries from the query parameters of HTTP requests. It supports comma separated
+// field lists and expanded relations:
+//
+//	GET /authors?fields=id,name&expand=books.genre
+//
+// as well as the JSON:API conventions, where fields are given per resource type and relations are included:
+//
+//	GET /authors?fields[authors]=name&fields[books]=title&include=books
+//
+// All paths are validated against the schema, invalid paths are reported together in an *Error.
+package httpsel
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"pollex.nl/alacarte"
+)
+
+// Params are the names of the query parameters.
+type Params struct {
+	// Fields lists the selected fields, e.g. "fields=id,name,books.name". For JSON:API fields are given per type, e.g.
+	// "fields[authors]=name".
+	Fields string
+	// Expand lists the relations to load with all their fields, e.g. "expand=books.genre".
+	Expand string
+	// Include is Expand for JSON:API.
+	Include string
+	// Type is the JSON:API type of the schema, it defaults to the table. The types of included relations are the
+	// tables of their schemas.
+	Type string
+}
+
+// DefaultParams are the parameters used by FromRequest.
+var DefaultParams = Params{Fields: "fields", Expand: "expand", Include: "include"}
+
+// Error lists the invalid paths of a request, it is meant to be returned as a 400 Bad Request.
+type Error struct {
+	Paths []InvalidPath
+}
+
+// InvalidPath is a path that does not resolve on the schema.
+type InvalidPath struct {
+	// Param is the query parameter the path was given in, such as "fields" or "fields[books]".
+	Param string
+	Path  string
+	Err   error
+}
+
+func (err *Error) Error() string {
+	paths := make([]string, len(err.Paths))
+	for ix, path := range err.Paths {
+		paths[ix] = fmt.Sprintf("%s: %s (%s)", path.Param, path.Path, path.Err)
+	}
+
+	return "invalid selection: " + strings.Join(paths, ", ")
+}
+
+func (err *Error) Unwrap() []error {
+	errs := make([]error, len(err.Paths))
+	for ix, path := range err.Paths {
+		errs[ix] = path.Err
+	}
+
+	return errs
+}
+
+// StatusCode is the HTTP status code for the error.
+func (err *Error) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// FromRequest creates a query on the schema from the query parameters of the request, using DefaultParams.
+func FromRequest[T any](schema *alacarte.ModelSchema[T], r *http.Request) (alacarte.ModelQuery[T], error) {
+	return FromValues(schema, r.URL.Query(), DefaultParams)
+}
+
+// FromValues creates a query on the schema from the query parameters. Without fields all fields of the schema are
+// selected, expanded and included relations are loaded with all their fields unless fields are given for their type.
+func FromValues[T any](
+	schema *alacarte.ModelSchema[T],
+	values url.Values,
+	params Params,
+) (alacarte.ModelQuery[T], error) {
+	var (
+		paths   []string
+		invalid []InvalidPath
+	)
+	add := func(param string, path string) {
+		if err := schema.Check(path); err != nil {
+			invalid = append(invalid, InvalidPath{Param: param, Path: path, Err: err})
+			return
+		}
+		paths = append(paths, path)
+	}
+
+	rootType := params.Type
+	if rootType == "" {
+		rootType = schema.Table
+	}
+	typeFields := typedFields(values, params.Fields)
+
+	if fields, ok := typeFields[rootType]; ok {
+		for _, field := range fields {
+			add(params.Fields+"["+rootType+"]", field)
+		}
+	} else if fields := list(values[params.Fields]); len(fields) > 0 {
+		for _, field := range fields {
+			add(params.Fields, field)
+		}
+	} else {
+		paths = append(paths, "*")
+	}
+
+	var relations []string
+	for _, param := range []string{params.Expand, params.Include} {
+		if param == "" {
+			continue
+		}
+		for _, path := range list(values[param]) {
+			if err := schema.Check(path); err != nil {
+				invalid = append(invalid, InvalidPath{Param: param, Path: path, Err: err})
+				continue
+			}
+			relations = append(relations, prefixes(path)...)
+		}
+	}
+
+	if len(relations) > 0 {
+		// The selection of the relations resolves the types of the included schemas
+		selection := schema.Query(relations...).Selection()
+		for _, relation := range compact(relations) {
+			typ := relationSelection(selection, relation).Table
+			fields, ok := typeFields[typ]
+			if !ok {
+				paths = append(paths, relation)
+				continue
+			}
+			for _, field := range fields {
+				add(params.Fields+"["+typ+"]", relation+"."+field)
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return alacarte.ModelQuery[T]{}, &Error{Paths: invalid}
+	}
+
+	return schema.Query(paths...), nil
+}
+
+// typedFields returns the JSON:API fields per type, e.g. "fields[books]=title".
+func typedFields(values url.Values, param string) map[string][]string {
+	fields := map[string][]string{}
+	for key, value := range values {
+		typ, ok := strings.CutPrefix(key, param+"[")
+		if !ok || !strings.HasSuffix(typ, "]") {
+			continue
+		}
+		fields[strings.TrimSuffix(typ, "]")] = list(value)
+	}
+
+	return fields
+}
+
+// list splits comma separated values, commas in relation arguments such as "books(limit:3,order:-id)" do not split.
+func list(values []string) []string {
+	var result []string
+	for _, value := range values {
+		depth, start := 0, 0
+		for ix, char := range value {
+			switch char {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			case ',':
+				if depth == 0 {
+					result = appendItem(result, value[start:ix])
+					start = ix + 1
+				}
+			}
+		}
+		result = appendItem(result, value[start:])
+	}
+
+	return result
+}
+
+func appendItem(items []string, item string) []string {
+	if item = strings.TrimSpace(item); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// prefixes returns the relation paths to load for an expanded path, e.g. "books" and "books.genre" for
+// "books.genre".
+func prefixes(path string) []string {
+	var (
+		result []string
+		depth  int
+	)
+	for ix, char := range path {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '.':
+			if depth == 0 {
+				result = append(result, path[:ix])
+			}
+		}
+	}
+
+	return append(result, path)
+}
+
+// relationSelection returns the selection of a relation path, arguments of the path are ignored.
+func relationSelection(selection alacarte.Selection, path string) alacarte.Selection {
+	for _, name := range strings.Split(stripArgs(path), ".") {
+		selection = selection.Relations[name]
+	}
+
+	return selection
+}
+
+func stripArgs(path string) string {
+	var (
+		builder strings.Builder
+		depth   int
+	)
+	for _, char := range path {
+		switch {
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case depth == 0:
+			builder.WriteRune(char)
+		}
+	}
+
+	return builder.String()
+}
+
+func compact(paths []string) []string {
+	result := []string{}
+	for _, path := range paths {
+		if !slices.Contains(result, path) {
+			result = append(result, path)
+		}
+	}
+
+	return result
+}
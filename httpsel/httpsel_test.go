@@ -0,0 +1,112 @@
+package httpsel_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/example"
+	"pollex.nl/alacarte/httpsel"
+)
+
+func TestFromValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params httpsel.Params
+		paths  []string
+	}{
+		{
+			name:  "all fields",
+			query: "",
+			paths: []string{"id", "name"},
+		},
+		{
+			name:  "comma separated fields",
+			query: "fields=id,name",
+			paths: []string{"id", "name"},
+		},
+		{
+			name:  "repeated fields",
+			query: "fields=id&fields=books.name",
+			paths: []string{"id", "books", "books.name"},
+		},
+		{
+			name:  "expand",
+			query: "fields=name&expand=books.genre",
+			paths: []string{"name", "books", "books.author_id", "books.genre_id", "books.id", "books.name",
+				"books.genre", "books.genre.id", "books.genre.name"},
+		},
+		{
+			name:  "relation arguments",
+			query: "fields=name,books(limit:3,order:-id).name",
+			paths: []string{"name", "books", "books.name"},
+		},
+		{
+			name:  "json api",
+			query: "fields[authors]=name&fields[books]=name&include=books",
+			paths: []string{"name", "books", "books.name"},
+		},
+		{
+			name:  "json api included types",
+			query: "fields[authors]=name&fields[genres]=name&include=books.genre",
+			paths: []string{"name", "books", "books.author_id", "books.genre_id", "books.id", "books.name",
+				"books.genre", "books.genre.name"},
+		},
+		{
+			name:   "custom params",
+			query:  "select[writers]=name&with=books",
+			params: httpsel.Params{Fields: "select", Expand: "with", Type: "writers"},
+			paths:  []string{"name", "books", "books.author_id", "books.genre_id", "books.id", "books.name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Arrange
+			values, err := url.ParseQuery(test.query)
+			require.NoError(t, err)
+			params := test.params
+			if params == (httpsel.Params{}) {
+				params = httpsel.DefaultParams
+			}
+
+			// Act
+			query, err := httpsel.FromValues(example.AuthorSchema, values, params)
+
+			// Assert
+			require.NoError(t, err)
+			require.NoError(t, query.Err())
+			assert.Equal(t, test.paths, query.Selection().Paths())
+		})
+	}
+}
+
+func TestFromRequestInvalid(t *testing.T) {
+	// Arrange
+	r := httptest.NewRequest(http.MethodGet, "/authors?fields=id,age&expand=boks&fields[genres]=title&include=books.genre", nil)
+
+	// Act
+	_, err := httpsel.FromRequest(example.AuthorSchema, r)
+
+	// Assert
+	var selectionErr *httpsel.Error
+	require.ErrorAs(t, err, &selectionErr)
+	assert.Equal(t, http.StatusBadRequest, selectionErr.StatusCode())
+	assert.Equal(t, []string{"age", "boks", "books.genre.title"}, paths(selectionErr))
+	assert.True(t, errors.Is(err, alacarte.ErrNoSuchField))
+	assert.Contains(t, err.Error(), "fields[genres]: books.genre.title")
+}
+
+func paths(err *httpsel.Error) []string {
+	result := make([]string, len(err.Paths))
+	for ix, path := range err.Paths {
+		result[ix] = path.Path
+	}
+	return result
+}
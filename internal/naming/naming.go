@@ -0,0 +1,29 @@
+// Package naming converts between the naming conventions of Go, GraphQL and SQL, so every package of alacarte derives
+// the same column and field names.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase converts a Go or GraphQL name such as AuthorID or authorId to author_id. A digit ends a word like a
+// lowercase letter does, so Book2ID and book2Id both become book2_id.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+
+	var builder strings.Builder
+	for ix, char := range runes {
+		if unicode.IsUpper(char) && ix > 0 {
+			previous := runes[ix-1]
+			previousLower := unicode.IsLower(previous) || unicode.IsDigit(previous)
+			nextLower := ix+1 < len(runes) && unicode.IsLower(runes[ix+1])
+			if previousLower || (nextLower && unicode.IsUpper(previous)) {
+				builder.WriteRune('_')
+			}
+		}
+		builder.WriteRune(unicode.ToLower(char))
+	}
+
+	return builder.String()
+}
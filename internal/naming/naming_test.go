@@ -0,0 +1,31 @@
+package naming_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pollex.nl/alacarte/internal/naming"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "ID", expected: "id"},
+		{name: "Name", expected: "name"},
+		{name: "AuthorID", expected: "author_id"},
+		{name: "authorId", expected: "author_id"},
+		{name: "HTTPServer", expected: "http_server"},
+		{name: "Book2ID", expected: "book2_id"},
+		{name: "book2Id", expected: "book2_id"},
+		{name: "Address2", expected: "address2"},
+		{name: "author_id", expected: "author_id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, naming.SnakeCase(test.name))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package alacarte
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// defaultBatchSize is the number of models Iter resolves the relations for at once, see ModelQuery.BatchSize.
+const defaultBatchSize = 500
+
+// Iter streams the models of the query, rather than collecting them all in memory. The relations are resolved for
+// batches of models, so only one batch is kept in memory while relations are still loaded in bulk. Iteration stops
+// at the first error.
+//
+// The relations are queried while the rows of the base query are still open, so db should be able to run more than
+// one query at a time, such as a *sql.DB with multiple connections. Iter can not stream before a cursor, see Before.
+func (model ModelQuery[T]) Iter(ctx context.Context, db squirrel.BaseRunner) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		model = model.withDependencies()
+		if err := model.Err(); err != nil {
+			yield(zero, err)
+			return
+		}
+		if model.backwards() {
+			yield(zero, fmt.Errorf("%w: Iter can not stream before a cursor", ErrInvalidCursor))
+			return
+		}
+
+		q, scan := model.selectQuery(db)
+		rows, err := q.QueryContext(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				slog.Default().Error("Iter: failed to close rows", "error", err.Error())
+			}
+		}()
+
+		batch := make([]T, 0, model.batchSize)
+		flush := func() bool {
+			if err := model.resolveRelations(ctx, db, batch); err != nil {
+				yield(zero, err)
+				return false
+			}
+			for _, t := range batch {
+				if !yield(t, nil) {
+					return false
+				}
+			}
+			batch = batch[:0]
+			return true
+		}
+
+		for rows.Next() {
+			var t T
+			pointers, action := scan(&t)
+			if err := rows.Scan(pointers...); err != nil {
+				yield(zero, err)
+				return
+			}
+			action()
+
+			batch = append(batch, t)
+			if len(batch) == cap(batch) && !flush() {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		if len(batch) > 0 {
+			flush()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestIter(t *testing.T) {
+	// Arrange, relations are queried while the base rows are open so the database needs multiple connections
+	db, sq := setupFileDB(t)
+	authors := sq.Insert("authors")
+	books := sq.Insert("books")
+	for id := 1; id <= 25; id++ {
+		authors = authors.Values(id, "Author", "tag")
+		books = books.Values(id*2, "First", id).Values(id*2+1, "Second", id)
+	}
+	_, err := authors.Exec()
+	require.NoError(t, err)
+	_, err = books.Exec()
+	require.NoError(t, err)
+
+	query := author.Query("id", "books.name").OrderBy("id").BatchSize(10)
+
+	t.Run("streams models with relations in batches", func(t *testing.T) {
+		var ids []uint64
+		for author, err := range query.Iter(context.Background(), db) {
+			require.NoError(t, err)
+			require.Len(t, author.Books, 2, "author %d", author.ID)
+			ids = append(ids, author.ID)
+		}
+
+		assert.Len(t, ids, 25)
+		assert.Equal(t, uint64(1), ids[0])
+		assert.Equal(t, uint64(25), ids[24])
+	})
+
+	t.Run("stops early", func(t *testing.T) {
+		count := 0
+		for range query.Iter(context.Background(), db) {
+			count++
+			if count == 12 {
+				break
+			}
+		}
+
+		assert.Equal(t, 12, count)
+	})
+
+	t.Run("yields query errors", func(t *testing.T) {
+		var errs []error
+		for _, err := range author.Query("age").Iter(context.Background(), db) {
+			errs = append(errs, err)
+		}
+
+		require.Len(t, errs, 1)
+		assert.True(t, errors.Is(errs[0], alacarte.ErrNoSuchField))
+	})
+}
@@ -0,0 +1,314 @@
+package alacarte
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// Selection is the tree of fields and relations selected by a query, see ModelQuery.Selection. It is used to encode
+// only the loaded fields of the results with MarshalJSON.
+type Selection struct {
+	// Table is the table of the selected schema.
+	Table string
+	// Fields are the selected fields, sorted by name.
+	Fields []string
+	// Relations are the selected relations, with the selection of the child schema.
+	Relations map[string]Selection
+
+	encoder encoder
+}
+
+// Paths returns the selected field paths, such as "name" and "books.name". Relations are included as well, e.g.
+// "books", so relations without selected fields are listed too.
+func (selection Selection) Paths() []string {
+	paths := slices.Clone(selection.Fields)
+	for _, name := range sortedKeys(selection.Relations) {
+		paths = append(paths, name)
+		for _, path := range selection.Relations[name].Paths() {
+			paths = append(paths, name+"."+path)
+		}
+	}
+
+	return paths
+}
+
+// Selection returns the fields and relations selected by the query. Fields that are selected automatically to bind
+// relations are not included.
+func (model ModelQuery[T]) Selection() Selection {
+	selection := Selection{
+		Table:     model.schema.Table,
+		Fields:    sortedKeys(model.selectedFields),
+		Relations: map[string]Selection{},
+		encoder:   &model.schema,
+	}
+	for name, relation := range model.selectedRelations {
+		if relation.selection != nil {
+			selection.Relations[name] = relation.selection(model.relationQueries[name].Fields)
+		} else {
+			selection.Relations[name] = Selection{}
+		}
+	}
+
+	return selection
+}
+
+// MarshalJSON encodes a result of a query, such as a T, *T or []T, with only the fields and relations in the
+// selection. The JSON names are set per field with SetJSONName, and default to the name in the json tag of the
+// struct field or else the field name. Fields are matched to struct fields by their alacarte, db or json tag, or by
+// name ignoring case and underscores, e.g. "author_id" matches AuthorID. Struct fields tagged json:"-" are left out,
+// unless SetJSONName names them.
+func MarshalJSON(result any, selection Selection) ([]byte, error) {
+	if selection.encoder == nil {
+		return json.Marshal(result)
+	}
+
+	value, err := selection.encoder.encode(reflect.ValueOf(result), selection)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// Sparse wraps a result, so it is encoded with only the selected fields when it is marshalled, for example as part of a
+// response.
+func Sparse(result any, selection Selection) json.Marshaler {
+	return sparse{result: result, selection: selection}
+}
+
+type sparse struct {
+	result    any
+	selection Selection
+}
+
+func (s sparse) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(s.result, s.selection)
+}
+
+// SetJSONName sets the name of a field or relation in the output of MarshalJSON.
+func (schema *ModelSchema[T]) SetJSONName(field, name string) *ModelSchema[T] {
+	if schema.JSONNames == nil {
+		schema.JSONNames = map[string]string{}
+	}
+	schema.JSONNames[field] = name
+
+	return schema
+}
+
+// encoder encodes the models of a schema.
+type encoder interface {
+	encode(value reflect.Value, selection Selection) (any, error)
+}
+
+func (schema *ModelSchema[T]) encode(value reflect.Value, selection Selection) (any, error) {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return schema.encode(value.Elem(), selection)
+	case reflect.Slice, reflect.Array:
+		items := make([]any, value.Len())
+		for ix := range items {
+			item, err := schema.encode(value.Index(ix), selection)
+			if err != nil {
+				return nil, err
+			}
+			items[ix] = item
+		}
+		return items, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return schema.encodeModel(selection, func(name string) (reflect.Value, string, int, bool) {
+			field := value.MapIndex(reflect.ValueOf(name))
+			return field, "", 0, true
+		})
+	case reflect.Struct:
+		fields := structJSONFields(value.Type())
+		return schema.encodeModel(selection, func(name string) (reflect.Value, string, int, bool) {
+			field, ok := fields[name]
+			if !ok {
+				field, ok = fields[normalizeName(name)]
+			}
+			if !ok {
+				return reflect.Value{}, "", 0, false
+			}
+			fieldValue, err := value.FieldByIndexErr(field.index)
+			if err != nil {
+				// A nil embedded struct
+				return reflect.Value{}, field.json, field.index[0], true
+			}
+			return fieldValue, field.json, field.index[0], true
+		})
+	}
+
+	return nil, fmt.Errorf("%w: can not encode %s", ErrInvalidArgument, value.Type())
+}
+
+// encodeModel encodes the selected fields and relations of a model, lookup returns the value of a field with the name
+// from its json tag and its position. Fields with the json name "-" are left out, unless SetJSONName names them.
+func (schema *ModelSchema[T]) encodeModel(
+	selection Selection,
+	lookup func(name string) (value reflect.Value, jsonName string, position int, ok bool),
+) (any, error) {
+	var object jsonObject
+	add := func(name string, encode func(reflect.Value) (any, error)) error {
+		value, jsonName, position, ok := lookup(name)
+		if !ok {
+			return fmt.Errorf("%w: no struct field for %s", ErrNoSuchField, name)
+		}
+
+		encoded, err := encode(value)
+		if err != nil {
+			return err
+		}
+
+		key := name
+		if configured, ok := schema.JSONNames[name]; ok {
+			key = configured
+		} else if jsonName == "-" {
+			return nil
+		} else if jsonName != "" {
+			key = jsonName
+		}
+		object = append(object, jsonMember{key: key, value: encoded, position: position})
+
+		return nil
+	}
+
+	for _, name := range selection.Fields {
+		err := add(name, func(value reflect.Value) (any, error) {
+			if !value.IsValid() {
+				return nil, nil
+			}
+			return value.Interface(), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sortedKeys(selection.Relations) {
+		child := selection.Relations[name]
+		err := add(name, func(value reflect.Value) (any, error) {
+			if child.encoder == nil {
+				if !value.IsValid() {
+					return nil, nil
+				}
+				return value.Interface(), nil
+			}
+			encoded, err := child.encoder.encode(value, child)
+			if items, ok := encoded.([]any); ok && items == nil {
+				encoded = []any{}
+			}
+			return encoded, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(object, func(a, b jsonMember) int { return a.position - b.position })
+
+	return object, nil
+}
+
+// jsonObject is an object with its members in order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key      string
+	value    any
+	position int
+}
+
+func (object jsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for ix, member := range object {
+		if ix > 0 {
+			buffer.WriteByte(',')
+		}
+		key, err := json.Marshal(member.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+type structJSONField struct {
+	index []int
+	json  string
+}
+
+// structJSONFieldsCache holds the fields of struct types by reflect.Type.
+var structJSONFieldsCache sync.Map
+
+// structJSONFields returns the struct fields by the names they can be matched with, see MarshalJSON.
+func structJSONFields(structType reflect.Type) map[string]structJSONField {
+	if cached, ok := structJSONFieldsCache.Load(structType); ok {
+		return cached.(map[string]structJSONField)
+	}
+
+	tagged := map[string]structJSONField{}
+	named := map[string]structJSONField{}
+	for _, structField := range reflect.VisibleFields(structType) {
+		if !structField.IsExported() || structField.Anonymous {
+			continue
+		}
+
+		// Like encoding/json, only the tag "-" leaves a field out and not "-,"
+		jsonTag := structField.Tag.Get("json")
+		jsonName, _, _ := strings.Cut(jsonTag, ",")
+		if jsonName == "-" && jsonTag != "-" {
+			jsonName = ""
+		}
+		field := structJSONField{index: structField.Index, json: jsonName}
+
+		for _, tag := range []string{"alacarte", "db"} {
+			name, _, _ := strings.Cut(structField.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				tagged[name] = field
+			}
+		}
+		if jsonName != "" && jsonName != "-" {
+			tagged[jsonName] = lo.ValueOr(tagged, jsonName, field)
+		}
+		named[normalizeName(structField.Name)] = field
+	}
+
+	// Tags take precedence over names, names are stored normalized
+	for name, field := range tagged {
+		named[name] = field
+	}
+
+	structJSONFieldsCache.Store(structType, named)
+
+	return named
+}
+
+// normalizeName matches names ignoring case and underscores, e.g. AuthorID and author_id.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
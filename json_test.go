@@ -0,0 +1,116 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff", "a,b").Values(2, "Mo", "c").Exec()
+	sq.Insert("books").Values(1, "Life of Jeff", 1).Values(2, "Cooking like Jeff", 1).Exec()
+	sq.Insert("book_comments").Values(1, "Great", 1).Exec()
+
+	t.Run("only selected fields", func(t *testing.T) {
+		query := author.Query("id", "name").OrderBy("id")
+		authors, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := alacarte.MarshalJSON(authors, query.Selection())
+		require.NoError(t, err)
+		assert.Equal(t, `[{"id":1,"name":"Jeff"},{"id":2,"name":"Mo"}]`, string(data))
+	})
+
+	t.Run("expanded relations", func(t *testing.T) {
+		query := author.Query("name", "books(order:id).name", "books.comments.name").OrderBy("id")
+		authors, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := alacarte.MarshalJSON(authors, query.Selection())
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"name": "Jeff", "books": [
+				{"name": "Life of Jeff", "comments": [{"name": "Great"}]},
+				{"name": "Cooking like Jeff", "comments": []}
+			]},
+			{"name": "Mo", "books": []}
+		]`, string(data))
+		assert.Equal(t, []string{"name", "books", "books.name", "books.comments", "books.comments.name"},
+			query.Selection().Paths())
+	})
+
+	t.Run("belongs to and single results", func(t *testing.T) {
+		query := keyedComment.Query("name", "book.name")
+		found, err := query.CollectOne(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(map[string]any{"comment": alacarte.Sparse(found, query.Selection())})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"comment": {"name": "Great", "book": {"name": "Life of Jeff"}}}`, string(data))
+	})
+
+	t.Run("json names per field", func(t *testing.T) {
+		type Tagged struct {
+			ID       uint64 `json:"identifier"`
+			AuthorID uint64
+			Name     string
+		}
+		schema := alacarte.New[Tagged]("books").
+			AddSimpleField("id", func(t *Tagged) any { return &t.ID }).
+			AddSimpleField("author_id", func(t *Tagged) any { return &t.AuthorID }).
+			AddSimpleField("name", func(t *Tagged) any { return &t.Name }).
+			SetJSONName("name", "title")
+
+		query := schema.Query("id", "author_id", "name").Where(alacarte.Eq("id", 1))
+		books, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := alacarte.MarshalJSON(books, query.Selection())
+		require.NoError(t, err)
+		assert.Equal(t, `[{"identifier":1,"author_id":1,"title":"Life of Jeff"}]`, string(data))
+	})
+
+	t.Run("fields tagged json:\"-\"", func(t *testing.T) {
+		type Secret struct {
+			ID   uint64
+			Name string `json:"-"`
+		}
+		schema := alacarte.New[Secret]("books").
+			AddSimpleField("id", func(t *Secret) any { return &t.ID }).
+			AddSimpleField("name", func(t *Secret) any { return &t.Name })
+
+		query := schema.Query("id", "name").Where(alacarte.Eq("id", 1))
+		books, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		data, err := alacarte.MarshalJSON(books, query.Selection())
+		require.NoError(t, err)
+		assert.Equal(t, `[{"id":1}]`, string(data))
+
+		schema.SetJSONName("name", "title")
+		data, err = alacarte.MarshalJSON(books, schema.Query("id", "name").Selection())
+		require.NoError(t, err)
+		assert.Equal(t, `[{"id":1,"title":"Life of Jeff"}]`, string(data))
+	})
+
+	t.Run("fields without struct field", func(t *testing.T) {
+		type Other struct{ ID uint64 }
+		schema := alacarte.New[Other]("books").
+			AddSimpleField("id", func(t *Other) any { return &t.ID }).
+			AddSimpleField("name", func(t *Other) any { return new(string) })
+
+		query := schema.Query("name")
+		books, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		_, err = alacarte.MarshalJSON(books, query.Selection())
+		assert.ErrorIs(t, err, alacarte.ErrNoSuchField)
+	})
+}
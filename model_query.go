@@ -5,9 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/squirrel"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -17,29 +23,61 @@ var (
 	ErrNoSuchRelation = errors.New("relation does not exist")
 	// ErrTooManyResults is returned when CollectOne is called but returned many models
 	ErrTooManyResults = errors.New("too many result for CollectOne")
+	// ErrInvalidFilter is returned when a filter can not be applied to the query.
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrNotSingleColumn is returned when a field is used in an expression, but does not select exactly one column.
+	ErrNotSingleColumn = errors.New("field does not select a single column")
+	// ErrNotSortable is returned when ordering by a field that is marked as unsortable.
+	ErrNotSortable = errors.New("field is not sortable")
+	// ErrNotFilterable is returned when filtering on a field that is marked as unfilterable.
+	ErrNotFilterable = errors.New("field is not filterable")
+	// ErrInvalidCursor is returned when a pagination cursor is malformed, tampered with or does not match the query.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidTag is returned by FromStruct schemas when a struct tag can not be used.
+	ErrInvalidTag = errors.New("invalid struct tag")
+	// ErrInvalidArgument is returned when a relation in a selection has invalid arguments.
+	ErrInvalidArgument = errors.New("invalid relation argument")
+	// ErrNoSuchPreset is returned when selecting a preset that is not added to the schema.
+	ErrNoSuchPreset = errors.New("preset does not exist")
 )
 
 type ModelQuery[T any] struct {
 	schema ModelSchema[T]
 
-	selectedFields         map[string]FieldType[T]
-	selectedRelations      map[string]Relation[T]
-	selectedRelationFields map[string][]string
-	tableAlias             string
-	queryMods              []QueryMod
+	selectedFields    map[string]FieldType[T]
+	selectedRelations map[string]Relation[T]
+	relationQueries   map[string]RelationQuery
+	excludedFields    map[string]bool
+	tableAlias        string
+	queryMods         []QueryMod
+	orderBy           []ordering
+	limit             uint64
+	hasLimit          bool // a limit of zero only returns no models with LimitZero
+	offset            uint64
+	limitBy           string // column expression
+	limitPer          uint64
+	cursor            *cursor
+	cursorBefore      bool
+	workers           int
+	chunkSize         int
+	batchSize         int
 
 	errors []error
 }
 
 func newModelQuery[T any](schema ModelSchema[T], fields ...string) ModelQuery[T] {
 	query := ModelQuery[T]{
-		schema:                 schema,
-		selectedFields:         map[string]FieldType[T]{},
-		selectedRelations:      map[string]Relation[T]{},
-		selectedRelationFields: map[string][]string{},
-		tableAlias:             schema.Table,
-		queryMods:              []QueryMod{},
-		errors:                 []error{},
+		schema:            schema,
+		selectedFields:    map[string]FieldType[T]{},
+		selectedRelations: map[string]Relation[T]{},
+		relationQueries:   map[string]RelationQuery{},
+		excludedFields:    map[string]bool{},
+		tableAlias:        schema.Table,
+		queryMods:         []QueryMod{},
+		orderBy:           []ordering{},
+		chunkSize:         DefaultDialect.ChunkSize(),
+		batchSize:         defaultBatchSize,
+		errors:            slices.Clone(schema.errors),
 	}
 
 	return query.Select(fields...)
@@ -65,8 +103,31 @@ func (model ModelQuery[T]) Select(fieldNames ...string) ModelQuery[T] {
 }
 
 func (model *ModelQuery[T]) resolveSelect(name string) {
+	if excluded, ok := strings.CutPrefix(name, "-"); ok {
+		model.resolveExclude(excluded)
+		return
+	}
+	if preset, ok := strings.CutPrefix(name, "@"); ok {
+		fields, ok := model.schema.Presets[preset]
+		if !ok {
+			model.addError(fmt.Errorf("%w: %s", ErrNoSuchPreset, preset))
+			return
+		}
+		for _, field := range fields {
+			model.resolveSelect(field)
+		}
+		return
+	}
+
 	field, rest := isNested(name)
 
+	seg, err := parseSegment(field)
+	if err != nil {
+		model.addError(err)
+		return
+	}
+	field = seg.name
+
 	if field == "*" {
 		if rest != "" {
 			model.addError(fmt.Errorf("%w: %s", ErrNoSuchRelation, field))
@@ -85,16 +146,23 @@ func (model *ModelQuery[T]) resolveSelect(name string) {
 				return
 			}
 		}
+		if err := model.schema.checkSegment(seg); err != nil {
+			model.addError(err)
+			return
+		}
+		delete(model.excludedFields, field)
 		model.selectRelation(field, rest)
+		model.applySegment(seg)
 		return
 	}
 
 	if model.schema.hasField(field) {
-		// Fields cannot have nesting
-		if rest != "" {
+		// Fields cannot have nesting or arguments
+		if rest != "" || seg.hasArgs {
 			model.addError(fmt.Errorf("%w: %s", ErrNoSuchRelation, field))
 			return
 		}
+		delete(model.excludedFields, field)
 		model.selectField(field)
 		return
 	}
@@ -103,9 +171,50 @@ func (model *ModelQuery[T]) resolveSelect(name string) {
 	model.addError(fmt.Errorf("%w: %s", ErrNoSuchField, field))
 }
 
+// resolveExclude removes a field or relation from the selection. Excluded fields are not selected by "*", so the order
+// of "*" and "-body" does not matter. Excluding a relation field, such as "-books.content", excludes it in the child
+// query.
+func (model *ModelQuery[T]) resolveExclude(name string) {
+	if err := model.schema.Check("-" + name); err != nil {
+		model.addError(err)
+		return
+	}
+
+	field, rest := isNested(name)
+	switch {
+	case rest != "":
+		relQuery := model.relationQueries[field]
+		relQuery.Fields = append(relQuery.Fields, "-"+rest)
+		model.relationQueries[field] = relQuery
+	case model.schema.hasRelation(field):
+		delete(model.selectedRelations, field)
+		model.excludedFields[field] = true
+	default:
+		delete(model.selectedFields, field)
+		model.excludedFields[field] = true
+	}
+}
+
+// selectAllFields selects the default selection of the schema, or else all fields that are not hidden. Excluded fields
+// and relations are skipped, including the nested fields of excluded relations.
 func (model *ModelQuery[T]) selectAllFields() {
-	for name := range model.schema.Fields {
-		model.selectedFields[name] = model.schema.Fields[name]
+	if model.schema.Default != nil {
+		for _, name := range model.schema.Default {
+			field, _ := isNested(name)
+			if seg, err := parseSegment(field); err == nil {
+				field = seg.name
+			}
+			if !model.excludedFields[field] {
+				model.resolveSelect(name)
+			}
+		}
+		return
+	}
+
+	for name, field := range model.schema.Fields {
+		if !field.Hidden && !model.excludedFields[name] {
+			model.selectedFields[name] = field
+		}
 	}
 }
 
@@ -120,11 +229,214 @@ func (model *ModelQuery[T]) selectRelation(relName, relField string) {
 
 	model.selectedRelations[relName] = model.schema.Relations[relName]
 
-	if model.selectedRelationFields[relName] == nil {
-		model.selectedRelationFields[relName] = []string{}
+	relQuery := model.relationQueries[relName]
+	relQuery.Fields = append(relQuery.Fields, relField)
+	model.relationQueries[relName] = relQuery
+}
+
+// applySegment applies the arguments of a relation segment to its child query.
+func (model *ModelQuery[T]) applySegment(seg segment) {
+	relQuery := model.relationQueries[seg.name]
+	if seg.limit > 0 {
+		relQuery.Limit = seg.limit
+	}
+	for _, key := range seg.orderBy {
+		order := parseOrdering(key)
+		relQuery.OrderBy = append(relQuery.OrderBy, order.String())
+	}
+	model.relationQueries[seg.name] = relQuery
+}
+
+// Where filters the query on schema fields. Filters on relation fields, such as Eq("books.name", "..."), do not
+// filter the parents but the children loaded for that relation, so the relation must be selected. Filtering on a
+// relation that is not selected is an error.
+func (model ModelQuery[T]) Where(filters ...Filter) ModelQuery[T] {
+	for _, filter := range filters {
+		model.resolveWhere(filter)
+	}
+
+	return model
+}
+
+func (model *ModelQuery[T]) resolveWhere(filter Filter) {
+	relName, err := model.schema.filterRelation(filter)
+	if err != nil {
+		model.addError(err)
+		return
+	}
+
+	if relName != "" {
+		relQuery := model.relationQueries[relName]
+		relQuery.Filters = append(relQuery.Filters, filter.trimRelation())
+		model.relationQueries[relName] = relQuery
+		return
+	}
+
+	// Validate the filter now, so errors surface through Err
+	if _, err := model.schema.filterSql(filter, model.tableAlias); err != nil {
+		model.addError(err)
+		return
+	}
+
+	schema := model.schema
+	model.queryMods = append(model.queryMods, func(q Q, table string) Q {
+		where, _ := schema.filterSql(filter, table)
+		return q.Where(where)
+	})
+}
+
+// checkRelationFilters reports filters on relations that are not selected, which would otherwise be ignored.
+func (model ModelQuery[T]) checkRelationFilters() error {
+	for _, name := range slices.Sorted(maps.Keys(model.relationQueries)) {
+		if _, ok := model.selectedRelations[name]; !ok && len(model.relationQueries[name].Filters) > 0 {
+			return fmt.Errorf("%w: relation %s is not selected", ErrInvalidFilter, name)
+		}
+	}
+
+	return nil
+}
+
+// OrderBy orders the query by schema fields, prefix a field with "-" to sort descending. Ordering by a relation field,
+// such as "-books.id", orders the children loaded for that relation.
+func (model ModelQuery[T]) OrderBy(keys ...string) ModelQuery[T] {
+	for _, key := range keys {
+		model.resolveOrderBy(key)
 	}
 
-	model.selectedRelationFields[relName] = append(model.selectedRelationFields[relName], relField)
+	return model
+}
+
+func (model *ModelQuery[T]) resolveOrderBy(key string) {
+	order := parseOrdering(key)
+	field, rest := isNested(order.field)
+
+	if rest != "" {
+		if !model.schema.hasRelation(field) {
+			model.addError(fmt.Errorf("%w: %s", ErrNoSuchRelation, field))
+			return
+		}
+		if err := model.schema.Relations[field].Check(rest); err != nil {
+			model.addError(err)
+			return
+		}
+
+		relQuery := model.relationQueries[field]
+		relQuery.OrderBy = append(relQuery.OrderBy, ordering{field: rest, desc: order.desc}.String())
+		model.relationQueries[field] = relQuery
+		return
+	}
+
+	fieldType, ok := model.schema.Fields[field]
+	if !ok {
+		model.addError(fmt.Errorf("%w: %s", ErrNoSuchField, field))
+		return
+	}
+	if fieldType.Unsortable {
+		model.addError(fmt.Errorf("%w: %s", ErrNotSortable, field))
+		return
+	}
+	if _, err := model.schema.fieldColumn(field, model.tableAlias); err != nil {
+		model.addError(err)
+		return
+	}
+
+	model.orderBy = append(model.orderBy, order)
+}
+
+// Limit limits the number of returned models. Together with After or Before it sets the page size.
+func (model ModelQuery[T]) Limit(limit uint64) ModelQuery[T] {
+	model.limit, model.hasLimit = limit, limit > 0
+
+	return model
+}
+
+// LimitZero limits the query to no models at all, which Limit(0) does not as it removes the limit. The total of
+// CollectWithTotal and Count still count all matching models, so it can be used to only request the total.
+func (model ModelQuery[T]) LimitZero() ModelQuery[T] {
+	model.limit, model.hasLimit = 0, true
+
+	return model
+}
+
+// Offset skips the first models, in the ordering of the query.
+func (model ModelQuery[T]) Offset(offset uint64) ModelQuery[T] {
+	model.offset = offset
+
+	return model
+}
+
+// LimitBy limits the number of models per distinct value of field, for example to load the latest three books of
+// every author. The models are numbered with ROW_NUMBER() in the ordering of the query.
+func (model ModelQuery[T]) LimitBy(field string, limit uint64) ModelQuery[T] {
+	col, err := model.schema.fieldColumn(field, model.tableAlias)
+	if err != nil {
+		model.addError(err)
+		return model
+	}
+
+	model.limitBy, model.limitPer = col, limit
+
+	return model
+}
+
+// Parallel loads sibling relations concurrently, using at most the given number of workers for the whole tree of
+// relations. The first failing relation cancels the others. The children are bound once all siblings are loaded. Queries on a
+// *sql.Tx are always resolved sequentially.
+func (model ModelQuery[T]) Parallel(workers int) ModelQuery[T] {
+	model.workers = workers
+
+	return model
+}
+
+// ChunkSize sets the maximum number of parents per relation query. Relations on more parents are loaded with one query
+// per chunk, to stay within the parameter limit of the database. A size of zero disables chunking.
+func (model ModelQuery[T]) ChunkSize(size int) ModelQuery[T] {
+	model.chunkSize = size
+
+	return model
+}
+
+// BatchSize sets the number of models Iter resolves the relations for at once.
+func (model ModelQuery[T]) BatchSize(size int) ModelQuery[T] {
+	model.batchSize = max(size, 1)
+
+	return model
+}
+
+// Dialect sets the chunk size of relation queries to fit the parameter limit of the database.
+func (model ModelQuery[T]) Dialect(dialect Dialect) ModelQuery[T] {
+	return model.ChunkSize(dialect.ChunkSize())
+}
+
+// After continues a paginated query after the cursor returned by CollectPage. The cursor applies to the other finishers
+// as well, Count for example counts the models after the cursor. The query must have the ordering of the cursor.
+func (model ModelQuery[T]) After(token string) ModelQuery[T] {
+	model.resolveCursor(token, false)
+
+	return model
+}
+
+// Before continues a paginated query before the cursor returned by CollectPage, see After. Limited queries return the
+// models right before the cursor. Iter can not stream before a cursor.
+func (model ModelQuery[T]) Before(token string) ModelQuery[T] {
+	model.resolveCursor(token, true)
+
+	return model
+}
+
+func (model *ModelQuery[T]) resolveCursor(token string, before bool) {
+	model.cursor, model.cursorBefore = nil, before
+	if token == "" {
+		return
+	}
+
+	cursor, err := decodeCursor(model.schema.cursorKey(), token)
+	if err != nil {
+		model.addError(err)
+		return
+	}
+
+	model.cursor = cursor
 }
 
 // =================
@@ -132,10 +444,19 @@ func (model *ModelQuery[T]) selectRelation(relName, relField string) {
 // =================
 
 func (model ModelQuery[T]) Err() error {
-	return errors.Join(model.errors...)
+	errs := slices.Clip(model.errors)
+	if err := model.checkCursor(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := model.checkRelationFilters(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
 
 func (model ModelQuery[T]) Collect(ctx context.Context, db squirrel.BaseRunner) ([]T, error) {
+	model = model.withDependencies()
 	if err := model.Err(); err != nil {
 		return nil, err
 	}
@@ -153,6 +474,7 @@ func (model ModelQuery[T]) Collect(ctx context.Context, db squirrel.BaseRunner)
 }
 
 func (model ModelQuery[T]) CollectOne(ctx context.Context, db squirrel.BaseRunner) (*T, error) {
+	model = model.withDependencies()
 	if err := model.Err(); err != nil {
 		return nil, err
 	}
@@ -175,10 +497,118 @@ func (model ModelQuery[T]) CollectOne(ctx context.Context, db squirrel.BaseRunne
 	return &parents[0], nil
 }
 
+// Count returns the number of models matching the query, ignoring the selected fields, ordering and limit.
+func (model ModelQuery[T]) Count(ctx context.Context, db squirrel.BaseRunner) (uint64, error) {
+	if err := model.Err(); err != nil {
+		return 0, err
+	}
+
+	// Count over a subquery, so grouping or distinct rows added by mods are counted correctly.
+	inner := model.baseQuery(nil).RemoveColumns().Column("1")
+	q := squirrel.StatementBuilder.RunWith(db).Select("COUNT(*)").FromSelect(inner, "counted")
+
+	var count uint64
+	if err := q.QueryRowContext(ctx).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Exists returns whether any model matches the query.
+func (model ModelQuery[T]) Exists(ctx context.Context, db squirrel.BaseRunner) (bool, error) {
+	if err := model.Err(); err != nil {
+		return false, err
+	}
+
+	q := model.baseQuery(db).RemoveColumns().Column("1").Limit(1)
+
+	rows, err := q.QueryContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Default().Error("Exists: failed to close rows", "error", err.Error())
+		}
+	}()
+
+	exists := rows.Next()
+
+	return exists, rows.Err()
+}
+
+// totalRow is a model together with the total number of rows of the query.
+type totalRow[T any] struct {
+	model T
+	total uint64
+}
+
+// CollectWithTotal collects the models like Collect, and returns the number of models matching the query regardless
+// of the limit and offset. The total is selected in the same query using the window function COUNT(*) OVER (), use
+// Count separately for databases that do not support window functions. An offset past the last model or LimitZero leave
+// no rows to read the total from, it is counted with Count instead.
+func (model ModelQuery[T]) CollectWithTotal(ctx context.Context, db squirrel.BaseRunner) ([]T, uint64, error) {
+	model = model.withDependencies()
+	if err := model.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	q, scan := model.selectQuery(db, "COUNT(*) OVER ()")
+
+	rows, err := Collect(ctx, q, func(row *totalRow[T]) (Ptrs, Action) {
+		return scan(&row.model, &row.total)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(rows) == 0 && (model.offset > 0 || model.hasLimit && model.limit == 0) {
+		total, err := model.Count(ctx, db)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []T{}, total, nil
+	}
+
+	if model.backwards() {
+		slices.Reverse(rows)
+	}
+
+	var total uint64
+	parents := make([]T, len(rows))
+	for ix := range rows {
+		parents[ix] = rows[ix].model
+		total = rows[ix].total
+	}
+
+	if err := model.resolveRelations(ctx, db, parents); err != nil {
+		return nil, 0, err
+	}
+
+	return parents, total, nil
+}
+
 func (model ModelQuery[T]) collectBaseModels(
 	ctx context.Context,
 	db squirrel.BaseRunner,
 ) ([]T, error) {
+	q, scan := model.selectQuery(db)
+
+	// Execute query
+	parents, err := Collect(ctx, q, func(t *T) (Ptrs, Action) { return scan(t) })
+	if err != nil {
+		return nil, err
+	}
+	if model.backwards() {
+		slices.Reverse(parents)
+	}
+
+	return parents, nil
+}
+
+// baseQuery builds the query with the schema and runtime mods applied, without ordering, limit or fields.
+func (model ModelQuery[T]) baseQuery(db squirrel.BaseRunner) Q {
 	q := squirrel.StatementBuilder.RunWith(db).Select().From(model.schema.Table)
 
 	// Apply schema mods
@@ -186,25 +616,92 @@ func (model ModelQuery[T]) collectBaseModels(
 	// Apply runtime mods
 	q = applyMods(q, model.tableAlias, model.queryMods)
 
-	// Add relation field dependencies
-	for _, rel := range model.selectedRelations {
+	if model.cursor != nil {
+		q = q.Where(model.keysetCondition(model.cursorOrdering(), model.cursor.Values))
+	}
+
+	return q
+}
+
+// withDependencies selects the fields the selected relations depend on, such as their keys. The selection is copied,
+// so the query it is called on does not change.
+func (model ModelQuery[T]) withDependencies() ModelQuery[T] {
+	relations := model.selectedRelations
+
+	model.selectedFields = maps.Clone(model.selectedFields)
+	model.selectedRelations = maps.Clone(model.selectedRelations)
+	model.relationQueries = maps.Clone(model.relationQueries)
+
+	for _, rel := range relations {
+		if rel.ParentKey != "" {
+			model.selectField(rel.ParentKey)
+		}
 		model = rel.ModelQueryMod(model)
 	}
 
+	return model
+}
+
+// selectQuery builds the query for the base models and its scanner. The extra columns are selected after the fields,
+// pointers for them are passed to the scanner.
+func (model ModelQuery[T]) selectQuery(db squirrel.BaseRunner, extra ...string) (Q, scanner[T]) {
+	q := model.baseQuery(db)
+
 	// Collapse fields
 	var scans []RowScan[T]
 	for _, field := range model.selectedFields {
 		q = field.Mod(q, model.tableAlias)
 		scans = append(scans, field.RowScan)
 	}
+	q = q.Columns(extra...)
 
-	// Execute query
-	parents, err := Collect(ctx, q, flattenRowScan(scans))
-	if err != nil {
-		return nil, err
+	// Paginating backwards reverses the ordering, the rows are flipped back after scanning.
+	model.orderBy = model.cursorOrdering()
+
+	discard := 0
+	if model.limitBy != "" {
+		q = model.applyLimitBy(db, q)
+		discard++
+	} else {
+		q = model.applyOrderBy(q)
 	}
 
-	return parents, nil
+	if model.hasLimit {
+		q = q.Limit(model.limit)
+	}
+	if model.offset > 0 {
+		if !model.hasLimit {
+			// Most databases require a limit with an offset
+			q = q.Limit(math.MaxInt64)
+		}
+		q = q.Offset(model.offset)
+	}
+
+	scan := flattenRowScan(scans)
+	return q, func(t *T, extra ...any) (Ptrs, Action) {
+		pointers, action := scan(t)
+		pointers = append(pointers, extra...)
+		for range discard {
+			var value any
+			pointers = append(pointers, &value)
+		}
+		return pointers, action
+	}
+}
+
+// applyLimitBy numbers the rows per partition and wraps the query to only return the first rows of every partition.
+// The row number is selected as the last column.
+func (model ModelQuery[T]) applyLimitBy(db squirrel.BaseRunner, q Q) Q {
+	over := "PARTITION BY " + model.limitBy
+	if terms := model.orderTerms(); len(terms) > 0 {
+		over += " ORDER BY " + strings.Join(terms, ", ")
+	}
+
+	return squirrel.StatementBuilder.RunWith(db).
+		Select("*").
+		FromSelect(q.Column("ROW_NUMBER() OVER ("+over+") AS alacarte_row"), "limited").
+		Where(squirrel.LtOrEq{"alacarte_row": model.limitPer}).
+		OrderBy("alacarte_row")
 }
 
 func (model ModelQuery[T]) resolveRelations(
@@ -212,22 +709,129 @@ func (model ModelQuery[T]) resolveRelations(
 	db squirrel.BaseRunner,
 	parents []T,
 ) error {
+	if model.workers > 1 && !isSingleConnection(db) {
+		return model.resolveRelationsParallel(ctx, db, parents)
+	}
+
 	// Resolve relations
 	for name, relation := range model.selectedRelations {
-		err := relation.Resolve(
-			ctx,
-			db,
-			parents,
-			model.selectedRelationFields[name],
-		)
+		bind, err := model.resolveRelation(ctx, db, parents, name, relation)
 		if err != nil {
 			return err
 		}
+		bind()
 	}
 
 	return nil
 }
 
+// workerPool holds the workers that are free to resolve relations. One pool is shared through the context by all nested
+// queries, so the number of workers does not grow with the depth of the relations.
+type workerPool chan struct{}
+
+type workerPoolKey struct{}
+
+// resolveRelationsParallel loads sibling relations concurrently, the first error cancels the other relations. The
+// children are bound to the parents after all relations are loaded.
+func (model ModelQuery[T]) resolveRelationsParallel(
+	ctx context.Context,
+	db squirrel.BaseRunner,
+	parents []T,
+) error {
+	pool, ok := ctx.Value(workerPoolKey{}).(workerPool)
+	if !ok {
+		// The calling goroutine is one of the workers
+		pool = make(workerPool, model.workers-1)
+		ctx = context.WithValue(ctx, workerPoolKey{}, pool)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	binds := make([]Action, 0, len(model.selectedRelations))
+	var mutex sync.Mutex
+
+	resolve := func(name string, relation Relation[T]) error {
+		bind, err := model.resolveRelation(groupCtx, db, parents, name, relation)
+		if err != nil {
+			return err
+		}
+
+		mutex.Lock()
+		binds = append(binds, bind)
+		mutex.Unlock()
+
+		return nil
+	}
+
+	for name, relation := range model.selectedRelations {
+		if groupCtx.Err() != nil {
+			break
+		}
+
+		select {
+		case pool <- struct{}{}:
+			group.Go(func() error {
+				defer func() { <-pool }()
+				return resolve(name, relation)
+			})
+		default:
+			// No worker is free, resolve the relation in this goroutine instead of waiting for workers that may be
+			// held by the parents of this query.
+			if err := resolve(name, relation); err != nil {
+				// A worker that failed first may have canceled this relation, prefer its error
+				failed := groupCtx.Err() != nil
+				cancel()
+				if groupErr := group.Wait(); failed && groupErr != nil {
+					return groupErr
+				}
+				return err
+			}
+		}
+	}
+
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	flattenActions(binds)()
+
+	return nil
+}
+
+func (model ModelQuery[T]) resolveRelation(
+	ctx context.Context,
+	db squirrel.BaseRunner,
+	parents []T,
+	name string,
+	relation Relation[T],
+) (Action, error) {
+	query := model.relationQueries[name]
+	query.PartitionBy = relation.Partition
+	query.Workers = model.workers
+	query.ChunkSize = model.chunkSize
+	if relation.ChildKey != "" {
+		query.Fields = append(slices.Clone(query.Fields), relation.ChildKey)
+	}
+
+	return relation.Resolve(
+		ctx,
+		db,
+		parents,
+		query,
+	)
+}
+
+// isSingleConnection reports whether db runs on a single connection, which can not run queries concurrently.
+func isSingleConnection(db squirrel.BaseRunner) bool {
+	_, ok := db.(*sql.Tx)
+	return ok
+}
+
 // =================
 // Utilities
 // =================
@@ -236,10 +840,21 @@ func (model *ModelQuery[T]) addError(err error) {
 	model.errors = append(model.errors, err)
 }
 
+// isNested splits the first segment from a selection path. Dots inside relation arguments, such as
+// "books(order:-id).name", do not split.
 func isNested(name string) (string, string) {
-	parts := strings.SplitN(name, ".", 2)
-	if len(parts) == 1 {
-		return name, ""
+	depth := 0
+	for ix, char := range name {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '.':
+			if depth == 0 {
+				return name[:ix], name[ix+1:]
+			}
+		}
 	}
-	return parts[0], parts[1]
+	return name, ""
 }
@@ -1,12 +1,27 @@
 package alacarte
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
 
 type ModelSchema[T any] struct {
 	Table     string
 	Fields    map[string]FieldType[T]
 	Relations map[string]Relation[T]
 	QueryMods []QueryMod
+	// CursorKey signs the cursors of paginated queries, see SetCursorKey.
+	CursorKey []byte
+	// JSONNames are the names of fields and relations in MarshalJSON, see SetJSONName.
+	JSONNames map[string]string
+	// Presets are named selections, see AddPreset.
+	Presets map[string][]string
+	// Default is the selection when no fields are given, see SetDefault.
+	Default []string
+
+	errors []error
 }
 
 func New[T any](table string) *ModelSchema[T] {
@@ -46,11 +61,128 @@ func (schema *ModelSchema[T]) AddRelation(
 	name string,
 	relation Relation[T],
 ) *ModelSchema[T] {
+	if relation.ParentKey != "" && !schema.hasField(relation.ParentKey) {
+		schema.errors = append(schema.errors,
+			fmt.Errorf("%w: parent key %s of relation %s", ErrNoSuchField, relation.ParentKey, name))
+	}
+	if relation.ChildKey != "" {
+		if err := relation.Check(relation.ChildKey); err != nil {
+			schema.errors = append(schema.errors, fmt.Errorf("child key of relation %s: %w", name, err))
+		}
+	}
+
 	schema.Relations[name] = relation
 
 	return schema
 }
 
+// AddPreset adds a named selection, which is selected with "@name", e.g. Query("@summary") or "books.@summary" for a
+// preset of the child schema. The fields can be anything Query accepts, including exclusions and other presets. They
+// are validated when the preset is added, invalid fields are reported by Err. Presets of the schema itself are
+// expanded when the preset is added, so they have to be added first.
+func (schema *ModelSchema[T]) AddPreset(name string, fields ...string) *ModelSchema[T] {
+	if schema.Presets == nil {
+		schema.Presets = map[string][]string{}
+	}
+	schema.Presets[name] = schema.expandSelection("preset "+name, fields)
+
+	return schema
+}
+
+// SetDefault sets the selection used when no fields are given: by Query and Select without fields, by "*" and by
+// relations selected without fields, such as "books". Without a default, these select all fields that are not
+// hidden, see FieldType.Hide. The fields are validated like AddPreset and can not contain "*". Relations in the
+// default can not lead back to it, such as "books" when the default of books selects "author" again, as every level
+// would select the next one.
+func (schema *ModelSchema[T]) SetDefault(fields ...string) *ModelSchema[T] {
+	expanded := schema.expandSelection("default selection", fields)
+	if slices.Contains(expanded, "*") {
+		schema.errors = append(schema.errors, fmt.Errorf("%w: default selection can not contain *", ErrInvalidArgument))
+		expanded = slices.DeleteFunc(expanded, func(field string) bool { return field == "*" })
+	}
+	expanded = slices.DeleteFunc(expanded, func(field string) bool {
+		if !schema.defaultCycle([]string{field}, map[any]bool{schema: true}) {
+			return false
+		}
+		schema.errors = append(schema.errors,
+			fmt.Errorf("%w: default selection can not contain %s, which leads back to it", ErrInvalidArgument, field))
+		return true
+	})
+	schema.Default = expanded
+
+	return schema
+}
+
+// defaultCycle reports whether the fields select the default selection of a schema that is already expanding its
+// default selection, directly or through the default selections of relations.
+func (schema *ModelSchema[T]) defaultCycle(fields []string, expanding map[any]bool) bool {
+	for _, field := range fields {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		if preset, ok := strings.CutPrefix(field, "@"); ok {
+			if schema.defaultCycle(schema.Presets[preset], expanding) {
+				return true
+			}
+			continue
+		}
+		if field == "*" {
+			if expanding[schema] {
+				return true
+			}
+			expanding[schema] = true
+			cycle := schema.defaultCycle(schema.Default, expanding)
+			delete(expanding, schema)
+			if cycle {
+				return true
+			}
+			continue
+		}
+
+		name, rest := isNested(field)
+		if seg, err := parseSegment(name); err == nil {
+			name = seg.name
+		}
+		relation, ok := schema.Relations[name]
+		if !ok || relation.defaultCycle == nil {
+			continue
+		}
+		if rest == "" {
+			rest = "*"
+		}
+		if relation.defaultCycle([]string{rest}, expanding) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandSelection validates the fields of a preset or default selection, and replaces the presets of the schema with
+// their fields. Invalid fields are added to the errors of the schema.
+func (schema *ModelSchema[T]) expandSelection(what string, fields []string) []string {
+	var expanded []string
+	for _, field := range fields {
+		if err := schema.Check(field); err != nil {
+			schema.errors = append(schema.errors, fmt.Errorf("%s: %w", what, err))
+			continue
+		}
+		if preset, ok := strings.CutPrefix(field, "@"); ok {
+			expanded = append(expanded, schema.Presets[preset]...)
+			continue
+		}
+		expanded = append(expanded, field)
+	}
+
+	return expanded
+}
+
+// Err returns the errors made while constructing the schema, such as relation keys that do not exist. Queries on the
+// schema return these errors as well.
+func (schema *ModelSchema[T]) Err() error {
+	return errors.Join(schema.errors...)
+}
+
 func (schema *ModelSchema[T]) ModifyQuery(mod QueryMod) *ModelSchema[T] {
 	schema.QueryMods = append(schema.QueryMods, mod)
 
@@ -61,9 +193,32 @@ func (schema *ModelSchema[T]) Query(fields ...string) ModelQuery[T] {
 	return newModelQuery(*schema, fields...)
 }
 
+// Check validates a path as accepted by Query, such as "name", "books(limit:3).name", "-books.content" or "@summary".
 func (schema *ModelSchema[T]) Check(field string) error {
+	if excluded, ok := strings.CutPrefix(field, "-"); ok {
+		if strings.HasPrefix(excluded, "@") || strings.Contains(excluded, "(") || excluded == "*" {
+			return fmt.Errorf("%w: can not exclude %s", ErrInvalidArgument, excluded)
+		}
+		field = excluded
+	}
+	if preset, ok := strings.CutPrefix(field, "@"); ok {
+		if _, ok := schema.Presets[preset]; !ok {
+			return fmt.Errorf("%w: %s", ErrNoSuchPreset, preset)
+		}
+		return nil
+	}
+	if field == "*" {
+		return nil
+	}
+
 	field, rest := isNested(field)
 
+	seg, err := parseSegment(field)
+	if err != nil {
+		return err
+	}
+	field = seg.name
+
 	if field == "" {
 		return nil
 	}
@@ -72,11 +227,11 @@ func (schema *ModelSchema[T]) Check(field string) error {
 		if err := schema.Relations[field].Check(rest); err != nil {
 			return err
 		}
-		return nil
+		return schema.checkSegment(seg)
 	}
 
 	if schema.hasField(field) {
-		if rest != "" {
+		if rest != "" || seg.hasArgs {
 			return fmt.Errorf("%w: %s", ErrNoSuchField, field)
 		}
 		return nil
@@ -85,6 +240,23 @@ func (schema *ModelSchema[T]) Check(field string) error {
 	return fmt.Errorf("%w: %s", ErrNoSuchField, field)
 }
 
+// checkSegment validates the arguments given to a relation.
+func (schema *ModelSchema[T]) checkSegment(seg segment) error {
+	relation := schema.Relations[seg.name]
+
+	if seg.limit > 0 && relation.Partition == "" {
+		return fmt.Errorf("%w: relation %s can not be limited per parent", ErrInvalidArgument, seg.name)
+	}
+
+	for _, key := range seg.orderBy {
+		if err := relation.Check(parseOrdering(key).field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (schema *ModelSchema[T]) hasRelation(name string) bool {
 	for k := range schema.Relations {
 		if k == name {
@@ -18,6 +18,11 @@ var (
 		AddSimpleField("name", func(t *Comment) any { return &t.Name }).
 		AddSimpleField("book_id", func(t *Comment) any { return &t.BookID })
 
+	//
+	genre = alacarte.New[Genre]("genres").
+		AddSimpleField("id", func(t *Genre) any { return &t.ID }).
+		AddSimpleField("name", func(t *Genre) any { return &t.Name })
+
 	//
 	book = alacarte.New[Book]("books").
 		AddSimpleField("id", func(t *Book) any { return &t.ID }).
@@ -30,21 +35,31 @@ var (
 				alacarte.WhereIDs("book_id", func(book Book) uint64 { return book.ID }),
 				alacarte.DependsOn("id", "comments.book_id"),
 			),
+		).
+		AddRelation("genres",
+			alacarte.ManyToMany(genre,
+				alacarte.JoinTable{Table: "book_genres", ParentKey: "book_id", ChildKey: "genre_id"},
+				func(book Book) uint64 { return book.ID },
+				func(book *Book, genres []Genre) { book.Genres = genres },
+				alacarte.DependsOn("id"),
+			),
 		)
 
 	//
 	author = alacarte.New[Author]("authors").
 		AddSimpleField("id", func(t *Author) any { return &t.ID }).
 		AddSimpleField("name", func(t *Author) any { return &t.Name }).
-		AddField(
+		AddFieldType(
 			"tags",
-			alacarte.Col("tags"),
-			func(t *Author) (alacarte.Ptrs, alacarte.Action) {
-				var tagString string
-				return alacarte.Ptrs{&tagString}, func() {
-					t.Tags = strings.Split(tagString, ",")
-				}
-			},
+			alacarte.Field(
+				alacarte.Col("tags"),
+				func(t *Author) (alacarte.Ptrs, alacarte.Action) {
+					var tagString string
+					return alacarte.Ptrs{&tagString}, func() {
+						t.Tags = strings.Split(tagString, ",")
+					}
+				},
+			).NotSortable(),
 		).
 		AddRelation(
 			"books",
@@ -53,10 +68,36 @@ var (
 				func(author *Author, books []Book) { author.Books = books },
 				alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
 				alacarte.DependsOn("id", "books.author_id"),
-			),
+			).PartitionBy("author_id"),
 		)
 )
 
+// keyedAuthor and keyedComment bind their relations by key, with the keys selected automatically.
+var keyedAuthor = alacarte.New[Author]("authors").
+	AddSimpleField("id", func(t *Author) any { return &t.ID }).
+	AddSimpleField("name", func(t *Author) any { return &t.Name }).
+	AddRelation("books",
+		alacarte.HasManyByKey(book,
+			func(author Author) uint64 { return author.ID },
+			func(book Book) uint64 { return book.AuthorID },
+			func(author *Author, books []Book) { author.Books = books },
+			alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "author_id").PartitionBy("author_id"),
+	)
+
+var keyedComment = alacarte.New[Comment]("book_comments").
+	AddSimpleField("id", func(t *Comment) any { return &t.ID }).
+	AddSimpleField("name", func(t *Comment) any { return &t.Name }).
+	AddSimpleField("book_id", func(t *Comment) any { return &t.BookID }).
+	AddRelation("book",
+		alacarte.BelongsTo(book,
+			"book_id", func(c Comment) uint64 { return c.BookID },
+			"id", func(b Book) uint64 { return b.ID },
+			func(c *Comment, b Book) { c.Book = &b },
+		),
+	)
+
 func init() {
 	comment.AddRelation(
 		"book",
@@ -217,3 +258,86 @@ func TestBasicModelRelation(t *testing.T) {
 		assert.Nil(t, author)
 	})
 }
+
+func TestCountAndExists(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").
+		Values(1, "Jeff", "cool,awesome").
+		Values(2, "Madonna", "vocal").
+		Values(3, "Prince", "purple").Exec()
+
+	t.Run("Count should ignore limit", func(t *testing.T) {
+		count, err := author.Query("id").
+			Where(alacarte.Gt("id", 1)).
+			Limit(1).
+			Count(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), count)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := author.Query().Where(alacarte.Eq("name", "Prince")).Exists(context.Background(), db)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = author.Query().Where(alacarte.Eq("name", "Cher")).Exists(context.Background(), db)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("CollectWithTotal", func(t *testing.T) {
+		authors, total, err := author.Query("id", "name").
+			OrderBy("id").
+			Limit(2).
+			CollectWithTotal(context.Background(), db)
+		require.NoError(t, err)
+		assert.Len(t, authors, 2)
+		assert.Equal(t, "Jeff", authors[0].Name)
+		assert.Equal(t, uint64(3), total)
+	})
+
+	t.Run("Offset", func(t *testing.T) {
+		authors, total, err := author.Query("name").
+			OrderBy("id").
+			Limit(1).
+			Offset(1).
+			CollectWithTotal(context.Background(), db)
+		require.NoError(t, err)
+		require.Len(t, authors, 1)
+		assert.Equal(t, "Madonna", authors[0].Name)
+		assert.Equal(t, uint64(3), total)
+
+		authors, total, err = author.Query("name").Offset(3).CollectWithTotal(context.Background(), db)
+		require.NoError(t, err)
+		assert.Empty(t, authors)
+		assert.Equal(t, uint64(3), total)
+
+		authors, err = author.Query("name").OrderBy("id").Offset(2).Collect(context.Background(), db)
+		require.NoError(t, err)
+		require.Len(t, authors, 1)
+		assert.Equal(t, "Prince", authors[0].Name)
+	})
+
+	t.Run("LimitZero", func(t *testing.T) {
+		query := author.Query("name").Limit(2).LimitZero()
+
+		authors, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+		assert.Empty(t, authors)
+
+		authors, total, err := query.CollectWithTotal(context.Background(), db)
+		require.NoError(t, err)
+		assert.Empty(t, authors)
+		assert.Equal(t, uint64(3), total)
+
+		authors, err = query.Limit(0).Collect(context.Background(), db)
+		require.NoError(t, err)
+		assert.Len(t, authors, 3)
+	})
+
+	t.Run("Count should report query errors", func(t *testing.T) {
+		_, err := author.Query("age").Count(context.Background(), db)
+		assert.ErrorIs(t, err, alacarte.ErrNoSuchField)
+	})
+}
@@ -0,0 +1,170 @@
+package odata
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenSemicolon
+	tokenEquals
+	tokenInvalid
+)
+
+func (kind tokenKind) String() string {
+	switch kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenIdent:
+		return "a name"
+	case tokenString:
+		return "a string"
+	case tokenNumber:
+		return "a number"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenComma:
+		return "','"
+	case tokenSemicolon:
+		return "';'"
+	case tokenEquals:
+		return "'='"
+	}
+
+	return "a valid token"
+}
+
+// token is a token of an option value, text is the unquoted value of strings.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	input  string
+	pos    int
+	peeked []token
+	// halted is set after a syntax error, the remaining input is ignored
+	halted bool
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input}
+}
+
+func (l *lexer) next() token {
+	if len(l.peeked) > 0 {
+		tok := l.peeked[0]
+		l.peeked = l.peeked[1:]
+		return tok
+	}
+
+	return l.scan()
+}
+
+func (l *lexer) peek() token {
+	return l.peekAt(0)
+}
+
+// peekAt returns the token after the next ahead tokens, without consuming them.
+func (l *lexer) peekAt(ahead int) token {
+	for len(l.peeked) <= ahead {
+		l.peeked = append(l.peeked, l.scan())
+	}
+
+	return l.peeked[ahead]
+}
+
+// halt ignores the remaining input.
+func (l *lexer) halt() {
+	l.halted = true
+	l.pos = len(l.input)
+	l.peeked = nil
+}
+
+func (l *lexer) scan() token {
+	for l.pos < len(l.input) && l.input[l.pos] == ' ' {
+		l.pos++
+	}
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, pos: l.pos}
+	}
+
+	start := l.pos
+	char, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	single := map[rune]tokenKind{
+		'(': tokenLParen, ')': tokenRParen, ',': tokenComma, ';': tokenSemicolon, '=': tokenEquals,
+	}
+	if kind, ok := single[char]; ok {
+		l.pos += size
+		return token{kind: kind, text: string(char), pos: start}
+	}
+
+	switch {
+	case char == '\'':
+		return l.scanString()
+	case char == '-' || unicode.IsDigit(char):
+		l.pos += size
+		for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokenNumber, text: l.input[start:l.pos], pos: start}
+	case isIdentStart(char):
+		for l.pos < len(l.input) {
+			char, size := utf8.DecodeRuneInString(l.input[l.pos:])
+			if !isIdentStart(char) && !unicode.IsDigit(char) && char != '/' {
+				break
+			}
+			l.pos += size
+		}
+		return token{kind: tokenIdent, text: l.input[start:l.pos], pos: start}
+	}
+
+	l.pos += size
+	return token{kind: tokenInvalid, text: string(char), pos: start}
+}
+
+// scanString scans a quoted string, in which a quote is escaped by doubling it.
+func (l *lexer) scanString() token {
+	start := l.pos
+	l.pos++
+
+	var text strings.Builder
+	for l.pos < len(l.input) {
+		char := l.input[l.pos]
+		l.pos++
+		if char != '\'' {
+			text.WriteByte(char)
+			continue
+		}
+		if l.pos < len(l.input) && l.input[l.pos] == '\'' {
+			text.WriteByte('\'')
+			l.pos++
+			continue
+		}
+		return token{kind: tokenString, text: text.String(), pos: start}
+	}
+
+	return token{kind: tokenInvalid, text: l.input[start:], pos: start}
+}
+
+func isIdentStart(char rune) bool {
+	return char == '_' || char == '$' || char == '*' || unicode.IsLetter(char)
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
@@ -0,0 +1,544 @@
+// Package odata creates alacarte queries from OData query options:
+//
+//	GET /authors?$select=name&$expand=books($select=name;$top=5;$orderby=id desc)&$filter=contains(name,'Jeff')
+//
+// $select, $expand, $filter, $orderby, $top and $skip are supported. Expanded relations take $select, $expand,
+// $filter, $orderby and $top as nested options, where $top limits the children per parent. Filters support the
+// comparisons eq, ne, gt, ge, lt and le, the functions contains, startswith and endswith, and and, or and not.
+// Navigation paths such as books/name select the fields of relations. They are not supported in $filter and $orderby,
+// where they would apply to the expanded relation instead of the models themselves; use the options of $expand.
+//
+// Every path is validated against the schema, errors are reported with the option and the position in its value.
+package odata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"pollex.nl/alacarte"
+)
+
+var (
+	// ErrSyntax is returned when an option can not be parsed.
+	ErrSyntax = errors.New("syntax error")
+	// ErrUnsupported is returned for options and functions that are not supported.
+	ErrUnsupported = errors.New("not supported")
+)
+
+// Error is an error in the value of a query option.
+type Error struct {
+	// Option is the query option, such as "$filter".
+	Option string
+	// Pos is the byte offset in the value of the option.
+	Pos int
+	Err error
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s at position %d: %s", err.Option, err.Pos, err.Err)
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// FromRequest creates a query on the schema from the OData query options of the request. Unlike Request.URL.Query, it
+// keeps the semicolons that separate the options of expanded relations.
+func FromRequest[T any](schema *alacarte.ModelSchema[T], r *http.Request) (alacarte.ModelQuery[T], error) {
+	values := url.Values{}
+	for _, part := range strings.Split(r.URL.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return alacarte.ModelQuery[T]{}, fmt.Errorf("%w: %w", ErrSyntax, err)
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			return alacarte.ModelQuery[T]{}, fmt.Errorf("%w: %s: %w", ErrSyntax, key, err)
+		}
+		values.Add(key, value)
+	}
+
+	return Query(schema, values)
+}
+
+// Query creates a query on the schema from the OData query options. Without $select all fields of the schema are
+// selected. Errors are returned as *Error, and together if several options fail.
+func Query[T any](schema *alacarte.ModelSchema[T], values url.Values) (alacarte.ModelQuery[T], error) {
+	b := &builder{
+		check: schema.Check,
+		isRelation: func(path string) bool {
+			selection := schema.Query(path).Selection()
+			for _, name := range strings.Split(path, ".") {
+				var ok bool
+				if selection, ok = selection.Relations[name]; !ok {
+					return false
+				}
+			}
+			return true
+		},
+		limits: map[string]uint64{},
+	}
+
+	var (
+		errs      []error
+		selected  bool
+		hasTop    bool
+		top, skip uint64
+	)
+	for _, option := range []string{"$select", "$expand", "$filter", "$orderby", "$top", "$skip"} {
+		value := values.Get(option)
+		if value == "" {
+			continue
+		}
+
+		p := &parser{builder: b, option: option, lexer: newLexer(value)}
+		switch option {
+		case "$select":
+			selected = true
+			p.parseSelect("")
+		case "$expand":
+			p.parseExpand("")
+		case "$filter":
+			b.filters = append(b.filters, p.parseFilter(""))
+		case "$orderby":
+			p.parseOrderBy("")
+		case "$top":
+			hasTop, top = true, p.parseCount()
+		case "$skip":
+			skip = p.parseCount()
+		}
+		p.expect(tokenEOF)
+		errs = append(errs, p.errs...)
+	}
+
+	if !selected {
+		b.paths = append([]string{"*"}, b.paths...)
+	}
+	if len(errs) > 0 {
+		return alacarte.ModelQuery[T]{}, errors.Join(errs...)
+	}
+
+	query := schema.Query(b.withLimits()...).
+		Where(b.filters...).
+		OrderBy(b.orderBy...).
+		Limit(top).
+		Offset(skip)
+	if hasTop && top == 0 {
+		// Limit(0) is no limit
+		query = query.LimitZero()
+	}
+
+	return query, query.Err()
+}
+
+// builder collects the selection, filters and ordering of all options.
+type builder struct {
+	check      func(path string) error
+	isRelation func(path string) bool
+
+	paths   []string
+	limits  map[string]uint64
+	filters []alacarte.Filter
+	orderBy []string
+}
+
+// withLimits returns the paths with the limit of every relation on the first path that selects it, e.g.
+// "books(limit:5).name" and "books.id".
+func (b *builder) withLimits() []string {
+	applied := map[string]bool{}
+	paths := make([]string, len(b.paths))
+	for ix, path := range b.paths {
+		names := strings.Split(path, ".")
+		for pos := range names {
+			relation := strings.Join(names[:pos+1], ".")
+			if limit, ok := b.limits[relation]; ok && !applied[relation] {
+				applied[relation] = true
+				names[pos] += fmt.Sprintf("(limit:%d)", limit)
+			}
+		}
+		paths[ix] = strings.Join(names, ".")
+	}
+
+	return paths
+}
+
+// parser parses the value of one option. Paths are resolved from prefix, the path of the expanded relation the
+// options apply to.
+type parser struct {
+	*builder
+	*lexer
+	option string
+	errs   []error
+	// invalid is set while parsing the options of an invalid relation, whose paths can not be validated
+	invalid bool
+}
+
+func (p *parser) fail(pos int, err error) {
+	p.errs = append(p.errs, &Error{Option: p.option, Pos: pos, Err: err})
+}
+
+// failSyntax reports an unexpected token and halts, the tokens that follow can not be trusted.
+func (p *parser) failSyntax(tok token, expected string) {
+	if p.halted {
+		return
+	}
+
+	found := strconv.Quote(tok.text)
+	if tok.kind == tokenEOF {
+		found = tok.kind.String()
+	}
+	p.fail(tok.pos, fmt.Errorf("%w: expected %s, found %s", ErrSyntax, expected, found))
+	p.halt()
+}
+
+func (p *parser) expect(kind tokenKind) (token, bool) {
+	tok := p.next()
+	if tok.kind != kind {
+		p.failSyntax(tok, kind.String())
+		return tok, false
+	}
+	return tok, true
+}
+
+// path reads a navigation path such as books/name and validates it relative to prefix. A relation path is accepted
+// when relation is set, otherwise the path has to refer to a field.
+func (p *parser) path(prefix string, relation bool) (string, token, bool) {
+	tok, ok := p.expect(tokenIdent)
+	if !ok || p.invalid {
+		return "", tok, false
+	}
+
+	path := join(prefix, strings.ReplaceAll(tok.text, "/", "."))
+	if err := p.check(path); err != nil {
+		p.fail(tok.pos, err)
+		return "", tok, false
+	}
+	if isRelation := p.isRelation(path); relation != isRelation {
+		if relation {
+			p.fail(tok.pos, fmt.Errorf("%w: %s is not a relation", alacarte.ErrNoSuchRelation, tok.text))
+		} else {
+			p.fail(tok.pos, fmt.Errorf("%w: %s is a relation", alacarte.ErrNoSuchField, tok.text))
+		}
+		return "", tok, false
+	}
+
+	return path, tok, true
+}
+
+// fieldPath reads the path of a field for $filter or $orderby, which can not be a navigation path.
+func (p *parser) fieldPath(prefix, option string) (string, token, bool) {
+	path, tok, ok := p.path(prefix, false)
+	if ok && strings.Contains(tok.text, "/") {
+		p.fail(tok.pos, fmt.Errorf("%w: navigation path %s in %s", ErrUnsupported, tok.text, option))
+		return "", tok, false
+	}
+
+	return path, tok, ok
+}
+
+// parseSelect parses `name, books/name` or `*`.
+func (p *parser) parseSelect(prefix string) {
+	for {
+		if p.peek().kind == tokenIdent && p.peek().text == "*" {
+			p.next()
+			if prefix == "" {
+				p.paths = append(p.paths, "*")
+			} else if !p.invalid {
+				// A relation path selects all its fields
+				p.paths = append(p.paths, prefix)
+			}
+		} else if path, tok, ok := p.path(prefix, false); ok {
+			p.paths = append(p.paths, path)
+		} else if tok.kind != tokenIdent {
+			return
+		}
+
+		if p.peek().kind != tokenComma {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseExpand parses `books($select=name;$expand=genre), author`.
+func (p *parser) parseExpand(prefix string) {
+	for {
+		path, tok, ok := p.path(prefix, true)
+		if !ok && tok.kind != tokenIdent {
+			return
+		}
+
+		selected := false
+		if p.peek().kind == tokenLParen {
+			p.next()
+			invalid := p.invalid
+			p.invalid = invalid || !ok
+			selected = p.parseExpandOptions(path)
+			p.invalid = invalid
+			if _, ok := p.expect(tokenRParen); !ok {
+				return
+			}
+		}
+		if ok && !selected {
+			p.paths = append(p.paths, path)
+		}
+
+		if p.peek().kind != tokenComma {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseExpandOptions parses the options of an expanded relation, and reports whether fields were selected.
+func (p *parser) parseExpandOptions(path string) bool {
+	selected := false
+	for {
+		tok, ok := p.expect(tokenIdent)
+		if !ok {
+			return selected
+		}
+		if _, ok := p.expect(tokenEquals); !ok {
+			return selected
+		}
+
+		switch tok.text {
+		case "$select":
+			selected = true
+			p.parseSelect(path)
+		case "$expand":
+			p.parseExpand(path)
+		case "$filter":
+			p.filters = append(p.filters, p.parseFilter(path))
+		case "$orderby":
+			p.parseOrderBy(path)
+		case "$top":
+			pos := p.peek().pos
+			top := p.parseCount()
+			if top == 0 && !p.halted {
+				p.fail(pos, fmt.Errorf("%w: $top=0 in $expand", ErrUnsupported))
+			}
+			if top > 0 && !p.invalid {
+				if err := p.check(fmt.Sprintf("%s(limit:%d)", path, top)); err != nil {
+					p.fail(pos, err)
+				}
+				p.limits[path] = top
+			}
+		default:
+			p.fail(tok.pos, fmt.Errorf("%w: %s in $expand", ErrUnsupported, tok.text))
+			p.halt()
+			return selected
+		}
+
+		if p.peek().kind != tokenSemicolon {
+			return selected
+		}
+		p.next()
+	}
+}
+
+// parseOrderBy parses `name desc, books/id`.
+func (p *parser) parseOrderBy(prefix string) {
+	for {
+		path, tok, ok := p.fieldPath(prefix, "$orderby")
+		if !ok && tok.kind != tokenIdent {
+			return
+		}
+
+		key := path
+		if next := p.peek(); next.kind == tokenIdent && (next.text == "asc" || next.text == "desc") {
+			p.next()
+			if next.text == "desc" {
+				key = "-" + path
+			}
+		}
+		if ok {
+			p.orderBy = append(p.orderBy, key)
+		}
+
+		if p.peek().kind != tokenComma {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseCount parses the value of $top or $skip.
+func (p *parser) parseCount() uint64 {
+	tok, ok := p.expect(tokenNumber)
+	if !ok {
+		return 0
+	}
+
+	count, err := strconv.ParseUint(tok.text, 10, 64)
+	if err != nil {
+		p.fail(tok.pos, fmt.Errorf("%w: %s is not a count", ErrSyntax, tok.text))
+	}
+
+	return count
+}
+
+// parseFilter parses a boolean expression, `or` binds weaker than `and`.
+func (p *parser) parseFilter(prefix string) alacarte.Filter {
+	filter := p.parseAnd(prefix)
+	for p.peekKeyword("or") {
+		p.next()
+		filter = alacarte.Or(filter, p.parseAnd(prefix))
+	}
+
+	return filter
+}
+
+func (p *parser) parseAnd(prefix string) alacarte.Filter {
+	filter := p.parseUnary(prefix)
+	for p.peekKeyword("and") {
+		p.next()
+		filter = alacarte.And(filter, p.parseUnary(prefix))
+	}
+
+	return filter
+}
+
+func (p *parser) parseUnary(prefix string) alacarte.Filter {
+	if p.peekKeyword("not") {
+		p.next()
+		return alacarte.Not(p.parseUnary(prefix))
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		filter := p.parseFilter(prefix)
+		p.expect(tokenRParen)
+		return filter
+	}
+
+	if next := p.peek(); next.kind == tokenIdent && p.peekAt(1).kind == tokenLParen {
+		return p.parseFunction(prefix)
+	}
+
+	return p.parseComparison(prefix)
+}
+
+// stringFunctions are the filters of the string functions, by name.
+var stringFunctions = map[string]func(field, text string) alacarte.Filter{
+	"contains":   alacarte.Contains,
+	"startswith": alacarte.StartsWith,
+	"endswith":   alacarte.EndsWith,
+}
+
+// parseFunction parses `contains(name,'Jeff')`.
+func (p *parser) parseFunction(prefix string) alacarte.Filter {
+	name := p.next()
+	function, known := stringFunctions[name.text]
+	if !known {
+		p.fail(name.pos, fmt.Errorf("%w: function %s", ErrUnsupported, name.text))
+		p.halt()
+		return alacarte.Filter{}
+	}
+	p.next()
+
+	path, _, ok := p.fieldPath(prefix, "$filter")
+	p.expect(tokenComma)
+	pos := p.peek().pos
+	value := p.parseLiteral()
+	p.expect(tokenRParen)
+
+	text, isString := value.(string)
+	if !isString && !p.halted {
+		p.fail(pos, fmt.Errorf("%w: %s requires a string", ErrSyntax, name.text))
+		return alacarte.Filter{}
+	}
+	if !ok {
+		return alacarte.Filter{}
+	}
+
+	return function(path, text)
+}
+
+// parseComparison parses `name eq 'Jeff'`.
+func (p *parser) parseComparison(prefix string) alacarte.Filter {
+	path, _, ok := p.fieldPath(prefix, "$filter")
+
+	op, isOp := p.expect(tokenIdent)
+	if !isOp {
+		return alacarte.Filter{}
+	}
+	value := p.parseLiteral()
+	if !ok {
+		return alacarte.Filter{}
+	}
+
+	switch op.text {
+	case "eq":
+		if value == nil {
+			return alacarte.IsNull(path)
+		}
+		return alacarte.Eq(path, value)
+	case "ne":
+		if value == nil {
+			return alacarte.Not(alacarte.IsNull(path))
+		}
+		return alacarte.NotEq(path, value)
+	case "gt":
+		return alacarte.Gt(path, value)
+	case "ge":
+		return alacarte.GtOrEq(path, value)
+	case "lt":
+		return alacarte.Lt(path, value)
+	case "le":
+		return alacarte.LtOrEq(path, value)
+	}
+
+	p.fail(op.pos, fmt.Errorf("%w: expected a comparison operator, found %q", ErrSyntax, op.text))
+
+	return alacarte.Filter{}
+}
+
+// parseLiteral parses a string, number, true, false or null.
+func (p *parser) parseLiteral() any {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return tok.text
+	case tokenNumber:
+		if value, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return value
+		}
+		if value, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return value
+		}
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+	}
+
+	p.failSyntax(tok, "a literal")
+
+	return nil
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	next := p.peek()
+	return next.kind == tokenIdent && next.text == keyword
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,314 @@
+//nolint:errcheck
+package odata_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+	"pollex.nl/alacarte/example"
+	"pollex.nl/alacarte/odata"
+)
+
+func setupDB(t *testing.T) (*sql.DB, squirrel.StatementBuilderType) {
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		create table authors (id integer not null, name text not null);
+		create table books (id integer not null, name text not null, author_id integer, genre_id integer);
+		create table genres (id integer not null, name text not null);
+	`)
+	require.NoError(t, err)
+
+	return db, squirrel.StatementBuilder.RunWith(db)
+}
+
+func TestQuery(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff").Values(2, "Mo").Values(3, "Jenny").Exec()
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1, 1).
+		Values(2, "Cooking like Jeff", 1, 2).
+		Values(3, "Jeff's diary", 1, 1).
+		Values(4, "Mo", 2, 1).Exec()
+	sq.Insert("genres").Values(1, "Biography").Values(2, "Cooking").Exec()
+
+	values := url.Values{
+		"$select":  {"name"},
+		"$expand":  {"books($select=name;$filter=name ne 'Jeff''s diary';$orderby=id desc;$top=1;$expand=genre($select=name))"},
+		"$filter":  {"startswith(name,'J') or (id eq 2 and not contains(name,'x'))"},
+		"$orderby": {"name desc"},
+		"$top":     {"2"},
+		"$skip":    {"1"},
+	}
+
+	// Act
+	query, err := odata.Query(example.AuthorSchema, values)
+	require.NoError(t, err)
+	authors, err := query.Collect(context.Background(), db)
+	require.NoError(t, err)
+
+	// Assert
+	data, err := alacarte.MarshalJSON(authors, query.Selection())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "Jenny", "books": []},
+		{"name": "Jeff", "books": [{"name": "Cooking like Jeff", "genre": {"name": "Cooking"}}]}
+	]`, string(data))
+}
+
+func TestQueryFilter(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff").Values(2, "Mo").Values(3, "Jenny").Values(4, "J_x").Exec()
+
+	tests := []struct {
+		name   string
+		values url.Values
+		names  []string
+	}{
+		{
+			name:   "contains",
+			values: url.Values{"$filter": {"contains(name,'e')"}},
+			names:  []string{"Jeff", "Jenny"},
+		},
+		{
+			name:   "wildcards are matched literally",
+			values: url.Values{"$filter": {`contains(name,'_') or startswith(name,'%') or endswith(name,'\')`}},
+			names:  []string{"J_x"},
+		},
+		{
+			name:   "top zero",
+			values: url.Values{"$top": {"0"}},
+			names:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.values.Set("$orderby", "id")
+
+			// Act
+			query, err := odata.Query(example.AuthorSchema, test.values)
+			require.NoError(t, err)
+			authors, err := query.Collect(context.Background(), db)
+			require.NoError(t, err)
+
+			// Assert
+			var names []string
+			for _, author := range authors {
+				names = append(names, author.Name)
+			}
+			assert.Equal(t, test.names, names)
+		})
+	}
+}
+
+func TestQueryTopZero(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff").Values(2, "Mo").Exec()
+
+	// Act
+	query, err := odata.Query(example.AuthorSchema, url.Values{"$top": {"0"}, "$filter": {"id gt 0"}})
+	require.NoError(t, err)
+	authors, total, err := query.CollectWithTotal(context.Background(), db)
+	require.NoError(t, err)
+	count, err := query.Count(context.Background(), db)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Empty(t, authors)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, uint64(2), count)
+}
+
+func TestQuerySelection(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		paths []string
+	}{
+		{
+			name:  "all fields",
+			query: "",
+			paths: []string{"id", "name"},
+		},
+		{
+			name:  "select",
+			query: "$select=id,%20name",
+			paths: []string{"id", "name"},
+		},
+		{
+			name:  "expand all fields",
+			query: "$select=name&$expand=books",
+			paths: []string{"name", "books", "books.author_id", "books.genre_id", "books.id", "books.name"},
+		},
+		{
+			name:  "nested expand",
+			query: "$select=name&$expand=books($select=name;$expand=genre($select=*))",
+			paths: []string{"name", "books", "books.name", "books.genre", "books.genre.id", "books.genre.name"},
+		},
+		{
+			name:  "navigation paths",
+			query: "$select=name,books/name",
+			paths: []string{"name", "books", "books.name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Arrange
+			r := httptest.NewRequest(http.MethodGet, "/authors?"+test.query, nil)
+
+			// Act
+			query, err := odata.FromRequest(example.AuthorSchema, r)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, test.paths, query.Selection().Paths())
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+		err    error
+		msg    string
+	}{
+		{
+			name:   "unknown field",
+			values: url.Values{"$select": {"name,age"}},
+			err:    alacarte.ErrNoSuchField,
+			msg:    "$select at position 5: field does not exist: age",
+		},
+		{
+			name:   "missing literal",
+			values: url.Values{"$filter": {"name eq"}},
+			err:    odata.ErrSyntax,
+			msg:    "$filter at position 7: syntax error: expected a literal, found end of input",
+		},
+		{
+			name:   "unknown operator",
+			values: url.Values{"$filter": {"name like 'J%'"}},
+			err:    odata.ErrSyntax,
+			msg:    `$filter at position 5: syntax error: expected a comparison operator, found "like"`,
+		},
+		{
+			name:   "unclosed parenthesis",
+			values: url.Values{"$filter": {"(id eq 1"}},
+			err:    odata.ErrSyntax,
+			msg:    "$filter at position 8: syntax error: expected ')', found end of input",
+		},
+		{
+			name:   "unterminated string",
+			values: url.Values{"$filter": {"name eq 'Jeff"}},
+			err:    odata.ErrSyntax,
+			msg:    `$filter at position 8: syntax error: expected a literal, found "'Jeff"`,
+		},
+		{
+			name:   "unknown function",
+			values: url.Values{"$filter": {"id eq 1 and length(name) gt 3"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$filter at position 12: not supported: function length",
+		},
+		{
+			name:   "function without string",
+			values: url.Values{"$filter": {"contains(name,3)"}},
+			err:    odata.ErrSyntax,
+			msg:    "$filter at position 14: syntax error: contains requires a string",
+		},
+		{
+			name:   "filter on relation",
+			values: url.Values{"$filter": {"books eq 1"}},
+			err:    alacarte.ErrNoSuchField,
+			msg:    "$filter at position 0: field does not exist: books is a relation",
+		},
+		{
+			name:   "navigation path in filter",
+			values: url.Values{"$filter": {"books/name eq 'x'"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$filter at position 0: not supported: navigation path books/name in $filter",
+		},
+		{
+			name:   "navigation path in expanded filter",
+			values: url.Values{"$expand": {"books($filter=contains(genre/name,'x'))"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$expand at position 23: not supported: navigation path genre/name in $filter",
+		},
+		{
+			name:   "navigation path in orderby",
+			values: url.Values{"$orderby": {"name,books/id desc"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$orderby at position 5: not supported: navigation path books/id in $orderby",
+		},
+		{
+			name:   "top zero in expand",
+			values: url.Values{"$expand": {"books($top=0)"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$expand at position 11: not supported: $top=0 in $expand",
+		},
+		{
+			name:   "expand field",
+			values: url.Values{"$expand": {"name"}},
+			err:    alacarte.ErrNoSuchRelation,
+			msg:    "$expand at position 0: relation does not exist: name is not a relation",
+		},
+		{
+			name:   "invalid expanded relation",
+			values: url.Values{"$expand": {"boks($select=title)"}},
+			err:    alacarte.ErrNoSuchField,
+			msg:    "$expand at position 0: field does not exist: boks",
+		},
+		{
+			name:   "nested path",
+			values: url.Values{"$expand": {"books($select=name;$orderby=title)"}},
+			err:    alacarte.ErrNoSuchField,
+			msg:    "$expand at position 28: field does not exist: title",
+		},
+		{
+			name:   "skip in expand",
+			values: url.Values{"$expand": {"books($skip=1)"}},
+			err:    odata.ErrUnsupported,
+			msg:    "$expand at position 6: not supported: $skip in $expand",
+		},
+		{
+			name:   "relation without partition",
+			values: url.Values{"$expand": {"books($expand=genre($top=1))"}},
+			err:    alacarte.ErrInvalidArgument,
+			msg:    "$expand at position 25: invalid relation argument: relation genre can not be limited per parent",
+		},
+		{
+			name:   "top",
+			values: url.Values{"$top": {"ten"}},
+			err:    odata.ErrSyntax,
+			msg:    `$top at position 0: syntax error: expected a number, found "ten"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Act
+			_, err := odata.Query(example.AuthorSchema, test.values)
+
+			// Assert
+			var odataErr *odata.Error
+			require.ErrorAs(t, err, &odataErr)
+			assert.ErrorIs(t, err, test.err)
+			assert.EqualError(t, err, test.msg)
+		})
+	}
+}
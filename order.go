@@ -0,0 +1,46 @@
+package alacarte
+
+import "strings"
+
+type ordering struct {
+	field string
+	desc  bool
+}
+
+// parseOrdering parses a sort key such as "name", "+name" or "-name".
+func parseOrdering(key string) ordering {
+	if field, ok := strings.CutPrefix(key, "-"); ok {
+		return ordering{field: field, desc: true}
+	}
+
+	return ordering{field: strings.TrimPrefix(key, "+")}
+}
+
+func (order ordering) String() string {
+	if order.desc {
+		return "-" + order.field
+	}
+	return order.field
+}
+
+func (model ModelQuery[T]) applyOrderBy(q Q) Q {
+	for _, term := range model.orderTerms() {
+		q = q.OrderBy(term)
+	}
+
+	return q
+}
+
+// orderTerms returns the ORDER BY expressions of the query.
+func (model ModelQuery[T]) orderTerms() []string {
+	terms := make([]string, 0, len(model.orderBy))
+	for _, order := range model.orderBy {
+		col, _ := model.schema.fieldColumn(order.field, model.tableAlias)
+		if order.desc {
+			col += " DESC"
+		}
+		terms = append(terms, col)
+	}
+
+	return terms
+}
@@ -0,0 +1,109 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestOrderBy(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").
+		Values(1, "Jeff", "cool,awesome").
+		Values(2, "Madonna", "vocal").
+		Values(3, "Prince", "purple").Exec()
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).
+		Values(3, "Sing baby sing", 2).Exec()
+
+	t.Run("descending", func(t *testing.T) {
+		authors, err := author.Query("id").
+			OrderBy("-id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 3)
+		assert.Equal(t, []uint64{3, 2, 1}, []uint64{authors[0].ID, authors[1].ID, authors[2].ID})
+	})
+
+	t.Run("relation field orders children", func(t *testing.T) {
+		authors, err := author.Query("id", "books.name").
+			Where(alacarte.Eq("id", 1)).
+			OrderBy("+books.name").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 1)
+		require.Len(t, authors[0].Books, 2)
+		assert.Equal(t, "Cooking like Jeff", authors[0].Books[0].Name)
+		assert.Equal(t, "Life of Jeff", authors[0].Books[1].Name)
+	})
+
+	t.Run("unsortable field", func(t *testing.T) {
+		query := author.Query("id").OrderBy("tags")
+		assert.ErrorIs(t, query.Err(), alacarte.ErrNotSortable)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		query := author.Query("id").OrderBy("-age", "books.isbn")
+		assert.ErrorIs(t, query.Err(), alacarte.ErrNoSuchField)
+	})
+}
+
+func TestRelationLimitPerParent(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").
+		Values(1, "Jeff", "cool,awesome").
+		Values(2, "Madonna", "vocal").Exec()
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).
+		Values(3, "Jeff returns", 1).
+		Values(4, "Sing baby sing", 2).
+		Values(5, "the singeth hath endeth", 2).Exec()
+	sq.Insert("book_comments").
+		Values(1, "Great book!", 3).
+		Values(2, "Very insightful", 3).
+		Values(3, "A masterpiece", 5).Exec()
+
+	t.Run("latest books per author", func(t *testing.T) {
+		authors, err := author.Query("id", "books(limit:2,order:-id).id").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 2)
+		require.Len(t, authors[0].Books, 2)
+		assert.Equal(t, []uint64{3, 2}, []uint64{authors[0].Books[0].ID, authors[0].Books[1].ID})
+		require.Len(t, authors[1].Books, 2)
+		assert.Equal(t, []uint64{5, 4}, []uint64{authors[1].Books[0].ID, authors[1].Books[1].ID})
+	})
+
+	t.Run("arguments on nested fields", func(t *testing.T) {
+		authors, err := author.Query("id", "books(order:-id,limit:1).comments.name").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 2)
+		require.Len(t, authors[0].Books, 1)
+		assert.Len(t, authors[0].Books[0].Comments, 2)
+		require.Len(t, authors[1].Books, 1)
+		assert.Len(t, authors[1].Books[0].Comments, 1)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		assert.ErrorIs(t, author.Query("books(limit:x)").Err(), alacarte.ErrInvalidArgument)
+		assert.ErrorIs(t, author.Query("books(size:1)").Err(), alacarte.ErrInvalidArgument)
+		assert.ErrorIs(t, author.Query("name(limit:1)").Err(), alacarte.ErrNoSuchRelation)
+		assert.ErrorIs(t, author.Query("books(order:-isbn)").Err(), alacarte.ErrNoSuchField)
+		assert.ErrorIs(t, author.Query("books.comments(limit:1)").Err(), alacarte.ErrInvalidArgument)
+	})
+}
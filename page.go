@@ -0,0 +1,315 @@
+package alacarte
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// defaultCursorKey signs cursors for schemas without a cursor key. It is generated on start-up, so cursors do not
+// survive restarts and are not shared between processes.
+var defaultCursorKey = func() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}()
+
+// Page is a page of models returned by ModelQuery.CollectPage.
+type Page[T any] struct {
+	Items []T
+	// Next is the cursor for the next page, or empty when there is no next page.
+	Next string
+	// Prev is the cursor for the previous page, or empty when there is no previous page.
+	Prev string
+}
+
+// cursor holds the values of the ordering fields of a row, and the ordering it was created for. Types holds the type
+// of each value that JSON does not preserve, such as times.
+type cursor struct {
+	OrderBy []string `json:"o"`
+	Values  []any    `json:"v"`
+	Types   []string `json:"t,omitempty"`
+}
+
+// cursorTime is the type of a time.Time value in a cursor, which is stored in RFC 3339 format.
+const cursorTime = "time"
+
+func encodeCursor(key []byte, orderBy []string, values []any) (string, error) {
+	cursor, err := newCursor(orderBy, values)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func decodeCursor(key []byte, token string) (*cursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var result cursor
+	if err := decoder.Decode(&result); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if len(result.Types) > 0 && len(result.Types) != len(result.Values) {
+		return nil, ErrInvalidCursor
+	}
+	for ix, value := range result.Values {
+		if number, ok := value.(json.Number); ok {
+			result.Values[ix] = numberValue(number)
+		}
+		if len(result.Types) > 0 && result.Types[ix] == cursorTime {
+			text, _ := value.(string)
+			t, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			result.Values[ix] = t
+		}
+	}
+
+	return &result, nil
+}
+
+// numberValue keeps integers as integers, so large ids survive the round trip through JSON.
+func numberValue(number json.Number) any {
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+	return number.String()
+}
+
+// SetCursorKey sets the key used to sign the cursors of paginated queries. Without it cursors are signed with a key
+// that is generated on start-up.
+func (schema *ModelSchema[T]) SetCursorKey(key []byte) *ModelSchema[T] {
+	schema.CursorKey = key
+
+	return schema
+}
+
+func (schema *ModelSchema[T]) cursorKey() []byte {
+	if len(schema.CursorKey) == 0 {
+		return defaultCursorKey
+	}
+	return schema.CursorKey
+}
+
+// pageRow is a model together with the values of its ordering columns.
+type pageRow[T any] struct {
+	model T
+	keys  []any
+}
+
+// CollectPage collects a page of models using keyset pagination. The query must be ordered, and the ordering should
+// be unique, for example by ending with the primary key. The ordering columns are selected alongside the fields to
+// create the cursors, regardless of the selected fields.
+func (model ModelQuery[T]) CollectPage(ctx context.Context, db squirrel.BaseRunner) (Page[T], error) {
+	model = model.withDependencies()
+	if err := model.Err(); err != nil {
+		return Page[T]{}, err
+	}
+	if len(model.orderBy) == 0 {
+		return Page[T]{}, fmt.Errorf("%w: pagination requires OrderBy", ErrInvalidCursor)
+	}
+	orderKeys := model.orderKeys()
+
+	// Query one more row than requested, to know whether there is another page.
+	pageQuery := model
+	if model.limit > 0 {
+		pageQuery.limit = model.limit + 1
+	}
+
+	keyColumns := make([]string, len(model.orderBy))
+	for ix, order := range model.orderBy {
+		keyColumns[ix], _ = model.schema.fieldColumn(order.field, model.tableAlias)
+	}
+
+	q, scan := pageQuery.selectQuery(db, keyColumns...)
+	rows, err := Collect(ctx, q, func(row *pageRow[T]) (Ptrs, Action) {
+		row.keys = make([]any, len(keyColumns))
+		keys := make([]any, len(keyColumns))
+		for ix := range row.keys {
+			keys[ix] = &row.keys[ix]
+		}
+		return scan(&row.model, keys...)
+	})
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	hasMore := model.limit > 0 && uint64(len(rows)) > model.limit
+	if hasMore {
+		rows = rows[:model.limit]
+	}
+	if model.backwards() {
+		slices.Reverse(rows)
+	}
+
+	var page Page[T]
+	if len(rows) > 0 {
+		first, last := rows[0].keys, rows[len(rows)-1].keys
+		hasPrev, hasNext := model.cursor != nil, hasMore
+		if model.cursorBefore {
+			hasPrev, hasNext = hasMore, model.cursor != nil
+		}
+
+		if hasPrev {
+			if page.Prev, err = encodeCursor(model.schema.cursorKey(), orderKeys, first); err != nil {
+				return Page[T]{}, err
+			}
+		}
+		if hasNext {
+			if page.Next, err = encodeCursor(model.schema.cursorKey(), orderKeys, last); err != nil {
+				return Page[T]{}, err
+			}
+		}
+	}
+
+	page.Items = make([]T, len(rows))
+	for ix := range rows {
+		page.Items[ix] = rows[ix].model
+	}
+
+	if err := model.resolveRelations(ctx, db, page.Items); err != nil {
+		return Page[T]{}, err
+	}
+
+	return page, nil
+}
+
+// orderKeys returns the ordering of the query as stored in cursors.
+func (model ModelQuery[T]) orderKeys() []string {
+	keys := make([]string, len(model.orderBy))
+	for ix, order := range model.orderBy {
+		keys[ix] = order.String()
+	}
+	return keys
+}
+
+// checkCursor reports a cursor that was created for another ordering than the one of the query.
+func (model ModelQuery[T]) checkCursor() error {
+	if model.cursor == nil {
+		return nil
+	}
+	if len(model.orderBy) == 0 {
+		return fmt.Errorf("%w: pagination requires OrderBy", ErrInvalidCursor)
+	}
+	if !slices.Equal(model.orderKeys(), model.cursor.OrderBy) || len(model.cursor.Values) != len(model.orderBy) {
+		return fmt.Errorf("%w: cursor does not match ordering", ErrInvalidCursor)
+	}
+
+	return nil
+}
+
+// backwards reports whether the query continues before a cursor, in which case the rows are queried in reverse.
+func (model ModelQuery[T]) backwards() bool {
+	return model.cursor != nil && model.cursorBefore
+}
+
+// cursorOrdering returns the ordering the rows are queried in, which is reversed when paginating backwards.
+func (model ModelQuery[T]) cursorOrdering() []ordering {
+	if !model.backwards() {
+		return model.orderBy
+	}
+
+	reversed := make([]ordering, len(model.orderBy))
+	for ix, order := range model.orderBy {
+		reversed[ix] = ordering{field: order.field, desc: !order.desc}
+	}
+	return reversed
+}
+
+// keysetCondition matches the rows that come after the values in the ordering:
+// (a > ?) OR (a = ? AND b > ?) OR ...
+func (model ModelQuery[T]) keysetCondition(orderBy []ordering, values []any) squirrel.Sqlizer {
+	var condition squirrel.Or
+	for ix, order := range orderBy {
+		var and squirrel.And
+		for jx, previous := range orderBy[:ix] {
+			col, _ := model.schema.fieldColumn(previous.field, model.tableAlias)
+			and = append(and, squirrel.Eq{col: values[jx]})
+		}
+
+		col, _ := model.schema.fieldColumn(order.field, model.tableAlias)
+		if order.desc {
+			and = append(and, squirrel.Lt{col: values[ix]})
+		} else {
+			and = append(and, squirrel.Gt{col: values[ix]})
+		}
+
+		condition = append(condition, and)
+	}
+
+	return condition
+}
+
+// newCursor makes the scanned values of the ordering fields JSON friendly, recording the types that JSON does not
+// preserve. Values of other types than those of driver.Value are rejected.
+func newCursor(orderBy []string, values []any) (cursor, error) {
+	result := cursor{OrderBy: orderBy, Values: make([]any, len(values))}
+	var types []string
+	for ix, value := range values {
+		valueType := ""
+		switch v := value.(type) {
+		case nil, bool, int64, float64, string:
+		case []byte:
+			value = string(v)
+		case time.Time:
+			value, valueType = v.Format(time.RFC3339Nano), cursorTime
+		default:
+			return cursor{}, fmt.Errorf("%w: ordering field %s of type %T can not be stored in a cursor",
+				ErrInvalidCursor, orderBy[ix], value)
+		}
+
+		if valueType != "" && types == nil {
+			types = make([]string, len(values))
+		}
+		if types != nil {
+			types[ix] = valueType
+		}
+		result.Values[ix] = value
+	}
+	result.Types = types
+
+	return result, nil
+}
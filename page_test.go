@@ -0,0 +1,175 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestCollectPage(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").
+		Values(1, "Jeff", "cool").
+		Values(2, "Madonna", "vocal").
+		Values(3, "Prince", "purple").
+		Values(4, "Bowie", "space").
+		Values(5, "Cher", "believe").Exec()
+
+	ids := func(authors []Author) []uint64 {
+		result := []uint64{}
+		for _, author := range authors {
+			result = append(result, author.ID)
+		}
+		return result
+	}
+
+	t.Run("pages forward and backward", func(t *testing.T) {
+		query := author.Query("name").OrderBy("-id").Limit(2)
+
+		first, err := query.CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Cher", "Bowie"}, []string{first.Items[0].Name, first.Items[1].Name})
+		assert.Empty(t, first.Items[0].ID, "ordering field is not selected on the model")
+		assert.Empty(t, first.Prev)
+		require.NotEmpty(t, first.Next)
+
+		second, err := query.After(first.Next).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Prince", "Madonna"}, []string{second.Items[0].Name, second.Items[1].Name})
+		require.NotEmpty(t, second.Next)
+		require.NotEmpty(t, second.Prev)
+
+		last, err := query.After(second.Next).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		require.Len(t, last.Items, 1)
+		assert.Empty(t, last.Next)
+
+		back, err := query.Before(second.Prev).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Cher", "Bowie"}, []string{back.Items[0].Name, back.Items[1].Name})
+		assert.Empty(t, back.Prev)
+		assert.NotEmpty(t, back.Next)
+	})
+
+	t.Run("multiple ordering fields", func(t *testing.T) {
+		query := author.Query("id").OrderBy("name", "id").Limit(3)
+
+		first, err := query.CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{4, 5, 1}, ids(first.Items))
+
+		second, err := query.After(first.Next).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 3}, ids(second.Items))
+	})
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		page, err := author.Query("id").OrderBy("id").Limit(2).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+
+		payload, signature, _ := strings.Cut(page.Next, ".")
+		query := author.Query("id").OrderBy("id").After(payload + "x." + signature)
+		assert.ErrorIs(t, query.Err(), alacarte.ErrInvalidCursor)
+	})
+
+	t.Run("cursor for other ordering", func(t *testing.T) {
+		page, err := author.Query("id").OrderBy("id").Limit(2).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+
+		_, err = author.Query("id").OrderBy("-id").After(page.Next).CollectPage(context.Background(), db)
+		assert.ErrorIs(t, err, alacarte.ErrInvalidCursor)
+	})
+	t.Run("cursor applies to other finishers", func(t *testing.T) {
+		query := author.Query("id").OrderBy("id").Limit(2)
+		page, err := query.CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		next, err := query.After(page.Next).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+
+		authors, err := query.After(page.Next).Collect(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{3, 4}, ids(authors))
+
+		authors, err = query.Before(next.Prev).Limit(1).Collect(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2}, ids(authors))
+
+		one, err := query.After(page.Next).Limit(1).CollectOne(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), one.ID)
+
+		authors, total, err := query.Before(next.Prev).CollectWithTotal(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{1, 2}, ids(authors))
+		assert.Equal(t, uint64(2), total)
+
+		count, err := query.After(page.Next).Count(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), count)
+
+		exists, err := query.After(page.Next).Where(alacarte.Eq("id", 1)).Exists(context.Background(), db)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		var streamed []Author
+		for author, err := range query.After(page.Next).Limit(0).Iter(context.Background(), db) {
+			require.NoError(t, err)
+			streamed = append(streamed, author)
+		}
+		assert.Equal(t, []uint64{3, 4, 5}, ids(streamed))
+
+		for _, err := range query.Before(next.Prev).Iter(context.Background(), db) {
+			assert.ErrorIs(t, err, alacarte.ErrInvalidCursor)
+		}
+		_, err = author.Query("id").After(page.Next).Count(context.Background(), db)
+		assert.ErrorIs(t, err, alacarte.ErrInvalidCursor)
+	})
+}
+
+func TestCollectPageByTime(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	_, err := db.Exec("create table events (id integer not null, created datetime not null)")
+	require.NoError(t, err)
+
+	type Event struct {
+		ID      uint64
+		Created time.Time
+	}
+	events := alacarte.New[Event]("events").
+		AddSimpleField("id", func(t *Event) any { return &t.ID }).
+		AddSimpleField("created", func(t *Event) any { return &t.Created })
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sq.Insert("events").
+		Values(1, start).
+		Values(2, start.Add(time.Hour)).
+		Values(3, start.Add(time.Hour)).
+		Values(4, start.Add(2*time.Hour)).
+		Values(5, start.Add(3*time.Hour)).Exec()
+
+	// Act
+	var ids []uint64
+	query := events.Query("id").OrderBy("created", "id").Limit(2)
+	for cursor := ""; ; {
+		page, err := query.After(cursor).CollectPage(context.Background(), db)
+		require.NoError(t, err)
+		for _, event := range page.Items {
+			ids = append(ids, event.ID)
+		}
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+
+	// Assert
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids)
+}
@@ -2,6 +2,7 @@ package alacarte
 
 import (
 	"github.com/Masterminds/squirrel"
+	"github.com/lann/builder"
 	"github.com/samber/lo"
 )
 
@@ -31,3 +32,11 @@ func applyMods(q Q, table string, mods []QueryMod) Q {
 
 	return q
 }
+
+// columnsOf returns the column expressions that the mod adds to a query.
+func columnsOf(mod QueryMod, table string) []squirrel.Sqlizer {
+	columns, _ := builder.Get(mod(squirrel.Select(), table), "Columns")
+	sqlizers, _ := columns.([]squirrel.Sqlizer)
+
+	return sqlizers
+}
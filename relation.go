@@ -8,16 +8,68 @@ import (
 )
 
 type (
-	Resolve[M any]            func(ctx context.Context, db squirrel.BaseRunner, parents []M, fields []string) error
-	FieldCheck                func(fields string) error
+	// Resolve loads the children of the parents, and returns the Action that binds them to the parents. The parents
+	// must only be read while loading, so sibling relations can be loaded concurrently.
+	Resolve[M any] func(ctx context.Context, db squirrel.BaseRunner, parents []M, query RelationQuery) (Action, error)
+	FieldCheck     func(fields string) error
+	// SchemaCheck validates the child schema against the database for ModelSchema.Validate.
+	SchemaCheck               func(ctx context.Context, db squirrel.BaseRunner, path string, seen validated) []FieldError
 	Binder[M, N any]          func(parents []M, children []N)
 	ModelQueryModifier[M any] func(model ModelQuery[M]) ModelQuery[M]
 )
 
+// RelationQuery describes the child query of a selected relation.
+type RelationQuery struct {
+	// Fields are the selected fields on the child schema.
+	Fields []string
+	// Filters are applied to the child query, with field names relative to the child schema.
+	Filters []Filter
+	// OrderBy orders the child query, with field names relative to the child schema.
+	OrderBy []string
+	// Limit limits the number of children per parent, see Relation.PartitionBy.
+	Limit uint64
+	// PartitionBy is the child field that refers to the parent.
+	PartitionBy string
+	// Workers is the number of workers to resolve the relations of the children with, see ModelQuery.Parallel.
+	Workers int
+	// ChunkSize is the maximum number of parents per child query, see ModelQuery.ChunkSize.
+	ChunkSize int
+}
+
 type Relation[M any] struct {
 	Resolve       Resolve[M]
 	Check         FieldCheck
 	ModelQueryMod ModelQueryModifier[M]
+	// Validate validates the child schema, relations without it are skipped by ModelSchema.Validate.
+	Validate SchemaCheck
+	// Partition is the child field that refers to the parent, it is required to limit the children per parent.
+	Partition string
+	// ParentKey is the parent field required to bind the relation, it is selected automatically.
+	ParentKey string
+	// ChildKey is the child field required to bind the relation, it is selected automatically.
+	ChildKey string
+
+	// selection returns the selection of the child schema for the selected child fields.
+	selection func(fields []string) Selection
+	// defaultCycle reports whether the selected child fields lead back to a default selection, see SetDefault.
+	defaultCycle func(fields []string, expanding map[any]bool) bool
+}
+
+// Keys sets the parent and child fields the relation is bound by, such as "id" and "author_id" for the books of an
+// author. These fields are selected automatically whenever the relation is selected, so they do not have to be added
+// with DependsOn. Adding the relation to a schema fails if the fields do not exist.
+func (relation Relation[M]) Keys(parentKey, childKey string) Relation[M] {
+	relation.ParentKey, relation.ChildKey = parentKey, childKey
+
+	return relation
+}
+
+// PartitionBy sets the child field that refers to the parent, such as "author_id" for the books of an author. This
+// allows limiting the number of children per parent, e.g. with the selection "books(limit:3,order:-id)".
+func (relation Relation[M]) PartitionBy(field string) Relation[M] {
+	relation.Partition = field
+
+	return relation
 }
 
 func HasMany[M, N any](
@@ -31,7 +83,7 @@ func HasMany[M, N any](
 		child,
 		BindBy(belongTogether, assign),
 		wherer,
-		func(model ModelQuery[M]) ModelQuery[M] { return model.Select(depends...) },
+		selectDepends[M](depends),
 	)
 }
 
@@ -46,7 +98,42 @@ func HasOne[M, N any](
 		child,
 		BindByOne(belongTogether, assign),
 		wherer,
-		func(model ModelQuery[M]) ModelQuery[M] { return model.Select(depends...) },
+		selectDepends[M](depends),
+	)
+}
+
+// HasManyByKey is HasMany, but binds the children to their parents by key instead of comparing every parent with every
+// child.
+func HasManyByKey[M, N any, K comparable](
+	child *ModelSchema[N],
+	parentKey func(M) K,
+	childKey func(N) K,
+	assign func(*M, []N),
+	wherer func(parents []M) QueryMod,
+	depends []string,
+) Relation[M] {
+	return CreateRelation(
+		child,
+		BindByKey(parentKey, childKey, assign),
+		wherer,
+		selectDepends[M](depends),
+	)
+}
+
+// HasOneByKey is HasOne, but binds the child to its parent by key instead of comparing every parent with every child.
+func HasOneByKey[M, N any, K comparable](
+	child *ModelSchema[N],
+	parentKey func(M) K,
+	childKey func(N) K,
+	assign func(*M, N),
+	wherer func(parents []M) QueryMod,
+	depends []string,
+) Relation[M] {
+	return CreateRelation(
+		child,
+		BindByKeyOne(parentKey, childKey, assign),
+		wherer,
+		selectDepends[M](depends),
 	)
 }
 
@@ -60,22 +147,205 @@ func CreateRelation[M, N any](
 		Check: func(field string) error {
 			return child.Check(field)
 		},
-		Resolve: func(ctx context.Context, db squirrel.BaseRunner, parents []M, fields []string) error {
-			children, err := child.Query(fields...).
-				ModifyQuery(wherer(parents)).
-				Collect(ctx, db)
-			if err != nil {
-				return err
-			}
+		Validate: child.validate,
+		selection: func(fields []string) Selection {
+			return child.Query(fields...).Selection()
+		},
+		defaultCycle: child.defaultCycle,
+		Resolve: func(ctx context.Context, db squirrel.BaseRunner, parents []M, query RelationQuery) (Action, error) {
+			var children []N
+			for _, parentChunk := range chunk(parents, query.ChunkSize) {
+				childQuery := newChildQuery(child, query).ModifyQuery(wherer(parentChunk))
+				if query.Limit > 0 {
+					childQuery = childQuery.LimitBy(query.PartitionBy, query.Limit)
+				}
 
-			binder(parents, children)
+				chunkChildren, err := childQuery.Collect(ctx, db)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, chunkChildren...)
+			}
 
-			return nil
+			return func() { binder(parents, children) }, nil
 		},
 		ModelQueryMod: depends,
 	}
 }
 
+// BelongsTo creates a relation to the owner of the parent, such as the book of a comment. The distinct foreign keys of
+// the parents are queried once and the owners are bound by their key. The foreign key field of the parent and the key
+// field of the owner are selected automatically.
+func BelongsTo[M, N any, K comparable](
+	owner *ModelSchema[N],
+	foreignKey string,
+	getForeignKey func(M) K,
+	ownerKey string,
+	getOwnerKey func(N) K,
+	assign func(*M, N),
+) Relation[M] {
+	return Relation[M]{
+		Check: func(field string) error {
+			return owner.Check(field)
+		},
+		Validate: owner.validate,
+		selection: func(fields []string) Selection {
+			return owner.Query(fields...).Selection()
+		},
+		defaultCycle: owner.defaultCycle,
+		Resolve: func(ctx context.Context, db squirrel.BaseRunner, parents []M, query RelationQuery) (Action, error) {
+			keys := lo.Uniq(lo.Map(parents, func(parent M, _ int) K { return getForeignKey(parent) }))
+
+			var owners []N
+			for _, keyChunk := range chunk(keys, query.ChunkSize) {
+				chunkOwners, err := newChildQuery(owner, query).
+					Where(In(ownerKey, keyChunk...)).
+					Collect(ctx, db)
+				if err != nil {
+					return nil, err
+				}
+				owners = append(owners, chunkOwners...)
+			}
+
+			return func() { BindByKeyOne(getForeignKey, getOwnerKey, assign)(parents, owners) }, nil
+		},
+		ModelQueryMod: func(model ModelQuery[M]) ModelQuery[M] { return model },
+		ParentKey:     foreignKey,
+		ChildKey:      ownerKey,
+	}
+}
+
+// JoinTable describes the table that links parents and children in a many-to-many relation.
+type JoinTable struct {
+	// Table is the name of the join table, e.g. "book_genres".
+	Table string
+	// ParentKey is the column that refers to the parent, e.g. "book_id".
+	ParentKey string
+	// ChildKey is the column that refers to the child, e.g. "genre_id".
+	ChildKey string
+	// ChildRef is the column of the child that ChildKey refers to, defaults to "id".
+	ChildRef string
+}
+
+// ManyToMany creates a relation through a join table. The child query joins the join table and selects its parent key
+// column, which is used to assign the children to their parents. The child does not need a field for the parent key.
+// Children are limited per parent key of the join table.
+func ManyToMany[M, N any, K comparable](
+	child *ModelSchema[N],
+	join JoinTable,
+	parentKey func(M) K,
+	assign func(*M, []N),
+	depends []string,
+) Relation[M] {
+	if join.ChildRef == "" {
+		join.ChildRef = "id"
+	}
+	parentCol := TableCol(join.Table, join.ParentKey)
+	joinMod := func(q Q, table string) Q {
+		return q.Join(join.Table + " ON " + TableCol(join.Table, join.ChildKey) + " = " + TableCol(table, join.ChildRef))
+	}
+
+	return Relation[M]{
+		Check: func(field string) error {
+			return child.Check(field)
+		},
+		Validate: func(ctx context.Context, db squirrel.BaseRunner, path string, seen validated) []FieldError {
+			q := child.Query().ModifyQuery(joinMod).baseQuery(db).Column(parentCol).Limit(0)
+			if err := validateQuery(ctx, q); err != nil {
+				return []FieldError{{Path: path, Table: join.Table, Err: err}}
+			}
+
+			return child.validate(ctx, db, path, seen)
+		},
+		selection: func(fields []string) Selection {
+			return child.Query(fields...).Selection()
+		},
+		defaultCycle: child.defaultCycle,
+		Resolve: func(ctx context.Context, db squirrel.BaseRunner, parents []M, query RelationQuery) (Action, error) {
+			keys := lo.Uniq(lo.Map(parents, func(parent M, _ int) K { return parentKey(parent) }))
+
+			var (
+				children  []N
+				childKeys []K
+			)
+			for _, keyChunk := range chunk(keys, query.ChunkSize) {
+				childQuery := newChildQuery(child, query).
+					ModifyQuery(joinMod).
+					ModifyQuery(func(q Q, _ string) Q { return q.Where(squirrel.Eq{parentCol: keyChunk}) })
+				if query.Limit > 0 {
+					childQuery.limitBy, childQuery.limitPer = parentCol, query.Limit
+				}
+
+				chunkChildren, chunkKeys, err := collectKeyed[N, K](ctx, db, childQuery, parentCol)
+				if err != nil {
+					return nil, err
+				}
+				children, childKeys = append(children, chunkChildren...), append(childKeys, chunkKeys...)
+			}
+
+			return func() {
+				index := map[K][]N{}
+				for ix := range children {
+					index[childKeys[ix]] = append(index[childKeys[ix]], children[ix])
+				}
+				for ix := range parents {
+					assign(&parents[ix], index[parentKey(parents[ix])])
+				}
+			}, nil
+		},
+		ModelQueryMod: selectDepends[M](depends),
+		Partition:     join.ParentKey,
+	}
+}
+
+// keyedRow is a model together with the value of an extra column.
+type keyedRow[T, K any] struct {
+	model T
+	key   K
+}
+
+// collectKeyed collects the models like Collect, together with the value of the extra column for every model.
+func collectKeyed[T, K any](
+	ctx context.Context,
+	db squirrel.BaseRunner,
+	model ModelQuery[T],
+	col string,
+) ([]T, []K, error) {
+	model = model.withDependencies()
+	if err := model.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	q, scan := model.selectQuery(db, col)
+	rows, err := Collect(ctx, q, func(row *keyedRow[T, K]) (Ptrs, Action) {
+		return scan(&row.model, &row.key)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	models := make([]T, len(rows))
+	keys := make([]K, len(rows))
+	for ix := range rows {
+		models[ix], keys[ix] = rows[ix].model, rows[ix].key
+	}
+
+	if err := model.resolveRelations(ctx, db, models); err != nil {
+		return nil, nil, err
+	}
+
+	return models, keys, nil
+}
+
+// newChildQuery creates the query on the child schema of a relation.
+func newChildQuery[N any](child *ModelSchema[N], query RelationQuery) ModelQuery[N] {
+	return child.Query(query.Fields...).
+		Where(query.Filters...).
+		OrderBy(query.OrderBy...).
+		Parallel(query.Workers).
+		ChunkSize(query.ChunkSize)
+}
+
 func BindBy[M, N any](
 	belongTogether func(M, N) bool,
 	assign func(*M, []N),
@@ -119,6 +389,44 @@ func BindByOne[M, N any](
 	}
 }
 
+// BindByKey binds children to parents with an equal key. The children are indexed once, so binding takes linear time.
+func BindByKey[M, N any, K comparable](
+	parentKey func(M) K,
+	childKey func(N) K,
+	assign func(*M, []N),
+) Binder[M, N] {
+	return func(parents []M, children []N) {
+		index := lo.GroupBy(children, childKey)
+
+		for ix := range parents {
+			assign(&parents[ix], index[parentKey(parents[ix])])
+		}
+	}
+}
+
+// BindByKeyOne binds the child with an equal key to the parent, parents without such a child are left untouched.
+func BindByKeyOne[M, N any, K comparable](
+	parentKey func(M) K,
+	childKey func(N) K,
+	assign func(*M, N),
+) Binder[M, N] {
+	return func(parents []M, children []N) {
+		index := make(map[K]N, len(children))
+		// Keep the first child for every key, like BindByOne
+		for _, child := range children {
+			if _, ok := index[childKey(child)]; !ok {
+				index[childKey(child)] = child
+			}
+		}
+
+		for ix := range parents {
+			if child, ok := index[parentKey(parents[ix])]; ok {
+				assign(&parents[ix], child)
+			}
+		}
+	}
+}
+
 func WhereIDs[M any, K any](col string, getID func(m M) K) func(parents []M) QueryMod {
 	return func(parents []M) QueryMod {
 		return func(q Q, table string) Q {
@@ -137,3 +445,14 @@ func WhereIDs[M any, K any](col string, getID func(m M) K) func(parents []M) Que
 func DependsOn(fields ...string) []string {
 	return fields
 }
+
+// selectDepends selects the fields a relation depends on. Without fields nothing is selected, as Select without fields
+// selects the default selection.
+func selectDepends[M any](depends []string) ModelQueryModifier[M] {
+	return func(model ModelQuery[M]) ModelQuery[M] {
+		if len(depends) == 0 {
+			return model
+		}
+		return model.Select(depends...)
+	}
+}
@@ -0,0 +1,325 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestManyToMany(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).
+		Values(3, "Sing baby sing", 2).Exec()
+	sq.Insert("genres").
+		Values(1, "Biography").
+		Values(2, "Cooking").
+		Values(3, "Music").Exec()
+	sq.Insert("book_genres").
+		Values(1, 1).
+		Values(2, 1).
+		Values(2, 2).
+		Values(3, 3).
+		Values(3, 1).Exec()
+
+	t.Run("assigns children through join table", func(t *testing.T) {
+		books, err := book.Query("name", "genres(order:name).name").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		names := func(genres []Genre) []string {
+			result := []string{}
+			for _, genre := range genres {
+				result = append(result, genre.Name)
+			}
+			return result
+		}
+
+		require.Len(t, books, 3)
+		assert.Equal(t, []string{"Biography"}, names(books[0].Genres))
+		assert.Equal(t, []string{"Biography", "Cooking"}, names(books[1].Genres))
+		assert.Equal(t, []string{"Biography", "Music"}, names(books[2].Genres))
+	})
+
+	t.Run("limit per parent", func(t *testing.T) {
+		books, err := book.Query("id", "genres(limit:1,order:-id).id").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, books, 3)
+		for _, book := range books {
+			require.Len(t, book.Genres, 1)
+		}
+		assert.Equal(t, uint64(2), books[1].Genres[0].ID)
+		assert.Equal(t, uint64(3), books[2].Genres[0].ID)
+	})
+
+	t.Run("parents without children", func(t *testing.T) {
+		sq.Insert("books").Values(4, "Untitled", 2).Exec()
+
+		books, err := book.Query("id", "genres").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, books, 4)
+		assert.Empty(t, books[3].Genres)
+	})
+}
+
+func TestBelongsTo(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).Exec()
+	sq.Insert("book_comments").
+		Values(1, "Great book!", 1).
+		Values(2, "Very insightful", 1).
+		Values(3, "A masterpiece", 2).
+		Values(4, "Lost", 5).Exec()
+
+	t.Run("binds owner by key", func(t *testing.T) {
+		comments, err := keyedComment.Query("id", "book.name").
+			OrderBy("id").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, comments, 4)
+		assert.Equal(t, "Life of Jeff", comments[0].Book.Name)
+		assert.Equal(t, "Life of Jeff", comments[1].Book.Name)
+		assert.Equal(t, "Cooking like Jeff", comments[2].Book.Name)
+		assert.Nil(t, comments[3].Book)
+	})
+
+	t.Run("selects keys automatically", func(t *testing.T) {
+		comments, err := keyedComment.Query("book.name").Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, comments, 4)
+		assert.NotEmpty(t, comments[0].BookID)
+		assert.NotEmpty(t, comments[0].Book.ID)
+	})
+}
+
+func TestBindByKey(t *testing.T) {
+	authors := []Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	books := []Book{{ID: 1, AuthorID: 2}, {ID: 2, AuthorID: 1}, {ID: 3, AuthorID: 2}}
+
+	t.Run("many", func(t *testing.T) {
+		alacarte.BindByKey(
+			func(a Author) uint64 { return a.ID },
+			func(b Book) uint64 { return b.AuthorID },
+			func(a *Author, books []Book) { a.Books = books },
+		)(authors, books)
+
+		assert.Equal(t, []Book{books[1]}, authors[0].Books)
+		assert.Equal(t, []Book{books[0], books[2]}, authors[1].Books)
+		assert.Empty(t, authors[2].Books)
+	})
+
+	t.Run("one", func(t *testing.T) {
+		comments := []Comment{{ID: 1, BookID: 3}, {ID: 2, BookID: 4}}
+
+		alacarte.BindByKeyOne(
+			func(c Comment) uint64 { return c.BookID },
+			func(b Book) uint64 { return b.ID },
+			func(c *Comment, b Book) { c.Book = &b },
+		)(comments, books)
+
+		require.NotNil(t, comments[0].Book)
+		assert.Equal(t, uint64(3), comments[0].Book.ID)
+		assert.Nil(t, comments[1].Book)
+	})
+}
+
+func TestRelationKeys(t *testing.T) {
+	t.Run("unknown keys fail the schema", func(t *testing.T) {
+		schema := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddRelation("books",
+				alacarte.HasManyByKey(book,
+					func(author Author) uint64 { return author.ID },
+					func(book Book) uint64 { return book.AuthorID },
+					func(author *Author, books []Book) { author.Books = books },
+					alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+					alacarte.DependsOn(),
+				).Keys("identifier", "writer_id"),
+			)
+
+		err := schema.Err()
+		assert.ErrorIs(t, err, alacarte.ErrNoSuchField)
+		assert.ErrorContains(t, err, "identifier")
+		assert.ErrorContains(t, err, "writer_id")
+		assert.ErrorIs(t, schema.Query("id").Err(), alacarte.ErrNoSuchField)
+	})
+
+	t.Run("keys are selected automatically", func(t *testing.T) {
+		db, sq := setupDB(t)
+		sq.Insert("authors").Values(1, "Jeff", "cool").Exec()
+		sq.Insert("books").Values(1, "Life of Jeff", 1).Exec()
+
+		authors, err := keyedAuthor.Query("name", "books.name").Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, authors, 1)
+		require.Len(t, authors[0].Books, 1)
+		assert.Equal(t, uint64(1), authors[0].ID)
+		assert.Equal(t, uint64(1), authors[0].Books[0].AuthorID)
+		assert.Empty(t, authors[0].Books[0].ID)
+	})
+}
+
+func TestParallelRelations(t *testing.T) {
+	// Arrange
+	db, sq := setupFileDB(t)
+	sq.Insert("books").
+		Values(1, "Life of Jeff", 1).
+		Values(2, "Cooking like Jeff", 1).Exec()
+	sq.Insert("book_comments").
+		Values(1, "Great book!", 1).
+		Values(2, "Very insightful", 2).Exec()
+	sq.Insert("genres").Values(1, "Biography").Exec()
+	sq.Insert("book_genres").Values(1, 1).Values(2, 1).Exec()
+
+	t.Run("resolves sibling relations", func(t *testing.T) {
+		books, err := book.Query("id", "comments.name", "genres.name").
+			OrderBy("id").
+			Parallel(4).
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, books, 2)
+		for _, book := range books {
+			assert.Len(t, book.Comments, 1)
+			assert.Len(t, book.Genres, 1)
+		}
+	})
+
+	t.Run("transactions are resolved sequentially", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		books, err := book.Query("id", "comments.name", "genres.name").
+			Parallel(4).
+			Collect(context.Background(), tx)
+		require.NoError(t, err)
+		require.Len(t, books, 2)
+		assert.Len(t, books[0].Comments, 1)
+		assert.Len(t, books[0].Genres, 1)
+	})
+
+	t.Run("nested relations share the workers", func(t *testing.T) {
+		runner := &concurrencyRunner{DB: db}
+		schema := alacarte.New[Book]("books").
+			AddSimpleField("id", func(t *Book) any { return &t.ID }).
+			AddRelation("comments", book.Relations["comments"]).
+			AddRelation("reviews",
+				alacarte.HasMany(comment,
+					func(book Book, comment Comment) bool { return comment.BookID == book.ID },
+					func(book *Book, comments []Comment) {},
+					alacarte.WhereIDs("book_id", func(book Book) uint64 { return book.ID }),
+					alacarte.DependsOn("id", "reviews.book_id"),
+				),
+			)
+
+		books, err := schema.Query(
+			"comments.book.comments.name", "comments.book.genres.name",
+			"reviews.book.comments.name", "reviews.book.genres.name",
+		).
+			OrderBy("id").
+			Parallel(2).
+			Collect(context.Background(), runner)
+		require.NoError(t, err)
+
+		require.Len(t, books, 2)
+		assert.Len(t, books[0].Comments[0].Book.Genres, 1)
+		assert.LessOrEqual(t, runner.max.Load(), int64(2))
+	})
+
+	t.Run("returns the first error", func(t *testing.T) {
+		missing := alacarte.New[Comment]("missing_table").
+			AddSimpleField("id", func(t *Comment) any { return &t.ID })
+		schema := alacarte.New[Book]("books").
+			AddSimpleField("id", func(t *Book) any { return &t.ID }).
+			AddRelation("comments", book.Relations["comments"]).
+			AddRelation("missing",
+				alacarte.HasManyByKey(missing,
+					func(book Book) uint64 { return book.ID },
+					func(comment Comment) uint64 { return comment.ID },
+					func(book *Book, comments []Comment) {},
+					alacarte.WhereIDs("id", func(book Book) uint64 { return book.ID }),
+					alacarte.DependsOn(),
+				),
+			)
+
+		_, err := schema.Query("id", "comments", "missing").
+			Parallel(2).
+			Collect(context.Background(), db)
+		assert.ErrorContains(t, err, "missing_table")
+	})
+}
+
+func TestChunkedRelations(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	for id := 1; id <= 5; id++ {
+		sq.Insert("authors").Values(id, "Author", "tag").Exec()
+		sq.Insert("books").Values(id*10, "Book", id).Values(id*10+1, "Book", id).Exec()
+		sq.Insert("book_comments").Values(id, "Comment", id*10).Exec()
+		sq.Insert("genres").Values(id, "Genre").Exec()
+		sq.Insert("book_genres").Values(id*10, id).Values(id*10+1, 1).Exec()
+	}
+
+	for _, size := range []int{0, 1, 2, 100} {
+		t.Run(fmt.Sprintf("chunk size %d", size), func(t *testing.T) {
+			authors, err := author.Query("id", "books.id", "books.genres.id", "books.comments.book").
+				OrderBy("id", "books.id").
+				ChunkSize(size).
+				Collect(context.Background(), db)
+			require.NoError(t, err)
+
+			require.Len(t, authors, 5)
+			for _, author := range authors {
+				require.Len(t, author.Books, 2)
+				assert.Equal(t, author.ID*10, author.Books[0].ID)
+				assert.Len(t, author.Books[0].Genres, 1)
+				assert.Len(t, author.Books[1].Genres, 1)
+				require.Len(t, author.Books[0].Comments, 1)
+				assert.Equal(t, author.Books[0].ID, author.Books[0].Comments[0].Book.ID)
+			}
+		})
+	}
+}
+
+// concurrencyRunner records the maximum number of queries that run at the same time.
+type concurrencyRunner struct {
+	*sql.DB
+	active, max atomic.Int64
+}
+
+func (r *concurrencyRunner) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	active := r.active.Add(1)
+	defer r.active.Add(-1)
+	for current := r.max.Load(); active > current && !r.max.CompareAndSwap(current, active); {
+		current = r.max.Load()
+	}
+
+	// Give the other workers time to start their queries
+	time.Sleep(10 * time.Millisecond)
+
+	return r.DB.QueryContext(ctx, query, args...)
+}
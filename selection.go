@@ -0,0 +1,52 @@
+package alacarte
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is one part of a selection path. Relations accept arguments, for example "books(limit:3,order:-id)" selects
+// the three books with the highest id per author.
+type segment struct {
+	name    string
+	limit   uint64
+	orderBy []string
+	hasArgs bool
+}
+
+func parseSegment(raw string) (segment, error) {
+	name, args, ok := strings.Cut(raw, "(")
+	if !ok {
+		return segment{name: raw}, nil
+	}
+
+	args, ok = strings.CutSuffix(args, ")")
+	if !ok {
+		return segment{}, fmt.Errorf("%w: %s", ErrInvalidArgument, raw)
+	}
+
+	seg := segment{name: name, hasArgs: true}
+	for _, arg := range strings.Split(args, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(arg), ":")
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "limit":
+			limit, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || limit == 0 {
+				return segment{}, fmt.Errorf("%w: limit %q on %s", ErrInvalidArgument, value, name)
+			}
+			seg.limit = limit
+		case "order":
+			if value == "" {
+				return segment{}, fmt.Errorf("%w: empty order on %s", ErrInvalidArgument, name)
+			}
+			seg.orderBy = append(seg.orderBy, value)
+		default:
+			return segment{}, fmt.Errorf("%w: %q on %s", ErrInvalidArgument, key, name)
+		}
+	}
+
+	return seg, nil
+}
@@ -0,0 +1,233 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestExclusion(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff", "a,b").Exec()
+	sq.Insert("books").Values(1, "Life of Jeff", 1).Exec()
+
+	t.Run("all fields except", func(t *testing.T) {
+		query := author.Query("*", "-tags")
+		authors, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"id", "name"}, query.Selection().Paths())
+		require.Len(t, authors, 1)
+		assert.Equal(t, "Jeff", authors[0].Name)
+		assert.Empty(t, authors[0].Tags)
+	})
+
+	t.Run("order does not matter", func(t *testing.T) {
+		assert.Equal(t, []string{"id", "name"}, author.Query("-tags", "*").Selection().Paths())
+	})
+
+	t.Run("relation fields", func(t *testing.T) {
+		query := author.Query("name", "books.*", "-books.name")
+		authors, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"name", "books", "books.author_id", "books.id"}, query.Selection().Paths())
+		require.Len(t, authors, 1)
+		require.Len(t, authors[0].Books, 1)
+		assert.Empty(t, authors[0].Books[0].Name)
+	})
+
+	t.Run("relations", func(t *testing.T) {
+		assert.Equal(t, []string{"name"}, author.Query("name", "books.name", "-books").Selection().Paths())
+	})
+
+	t.Run("relations in the default selection", func(t *testing.T) {
+		schema := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddSimpleField("name", func(t *Author) any { return &t.Name }).
+			AddRelation("books", author.Relations["books"]).
+			SetDefault("name", "books.name")
+
+		assert.Equal(t, []string{"name"}, schema.Query("*", "-books").Selection().Paths())
+		assert.Equal(t, []string{"name"}, schema.Query("-books", "*").Selection().Paths())
+
+		authors, err := schema.Query("-books", "*").Collect(context.Background(), db)
+		require.NoError(t, err)
+		require.Len(t, authors, 1)
+		assert.Empty(t, authors[0].Books)
+
+		query := schema.Query("-books", "*", "books.name")
+		assert.Equal(t, []string{"name", "books", "books.name"}, query.Selection().Paths())
+	})
+
+	t.Run("invalid exclusions", func(t *testing.T) {
+		assert.ErrorIs(t, author.Query("*", "-age").Err(), alacarte.ErrNoSuchField)
+		assert.ErrorIs(t, author.Query("*", "-books.age").Err(), alacarte.ErrNoSuchField)
+		assert.ErrorIs(t, author.Query("-*").Err(), alacarte.ErrInvalidArgument)
+		assert.ErrorIs(t, author.Query("-books(limit:1)").Err(), alacarte.ErrInvalidArgument)
+	})
+}
+
+func TestPresets(t *testing.T) {
+	// Arrange
+	books := alacarte.New[Book]("books").
+		AddSimpleField("id", func(t *Book) any { return &t.ID }).
+		AddSimpleField("name", func(t *Book) any { return &t.Name }).
+		AddSimpleField("author_id", func(t *Book) any { return &t.AuthorID }).
+		AddPreset("card", "id", "name")
+	schema := alacarte.New[Author]("authors").
+		AddSimpleField("id", func(t *Author) any { return &t.ID }).
+		AddSimpleField("name", func(t *Author) any { return &t.Name }).
+		AddRelation("books", alacarte.HasManyByKey(books,
+			func(author Author) uint64 { return author.ID },
+			func(book Book) uint64 { return book.AuthorID },
+			func(author *Author, books []Book) { author.Books = books },
+			alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "author_id")).
+		AddPreset("summary", "id", "name").
+		AddPreset("detail", "@summary", "books.id", "books.name")
+
+	t.Run("preset", func(t *testing.T) {
+		query := schema.Query("@summary")
+		require.NoError(t, query.Err())
+		assert.Equal(t, []string{"id", "name"}, query.Selection().Paths())
+	})
+
+	t.Run("nested presets", func(t *testing.T) {
+		assert.Equal(t, []string{"id", "name", "books.id", "books.name"}, schema.Presets["detail"])
+		assert.Equal(t, []string{"id", "name", "books", "books.id", "books.name"},
+			schema.Query("@detail").Selection().Paths())
+	})
+
+	t.Run("preset of a relation", func(t *testing.T) {
+		assert.Equal(t, []string{"name", "books", "books.id", "books.name"},
+			schema.Query("name", "books.@card").Selection().Paths())
+		assert.ErrorIs(t, schema.Query("books.@summary").Err(), alacarte.ErrNoSuchPreset)
+	})
+
+	t.Run("preset with other fields and exclusions", func(t *testing.T) {
+		assert.Equal(t, []string{"id", "books", "books.id"},
+			schema.Query("@detail", "-name", "-books.name").Selection().Paths())
+	})
+
+	t.Run("unknown preset", func(t *testing.T) {
+		assert.ErrorIs(t, schema.Query("@full").Err(), alacarte.ErrNoSuchPreset)
+		assert.ErrorIs(t, schema.Check("@full"), alacarte.ErrNoSuchPreset)
+	})
+
+	t.Run("validated when added", func(t *testing.T) {
+		invalid := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddPreset("summary", "id", "name", "@card")
+
+		assert.ErrorIs(t, invalid.Err(), alacarte.ErrNoSuchField)
+		assert.ErrorIs(t, invalid.Err(), alacarte.ErrNoSuchPreset)
+		assert.ErrorContains(t, invalid.Err(), "preset summary: field does not exist: name")
+		assert.ErrorIs(t, invalid.Query("id").Err(), alacarte.ErrNoSuchField)
+	})
+}
+
+func TestDefaultSelection(t *testing.T) {
+	// Arrange
+	db, sq := setupDB(t)
+	sq.Insert("authors").Values(1, "Jeff", "a,b").Exec()
+	sq.Insert("books").Values(1, "Life of Jeff", 1).Exec()
+
+	books := alacarte.New[Book]("books").
+		AddSimpleField("id", func(t *Book) any { return &t.ID }).
+		AddSimpleField("author_id", func(t *Book) any { return &t.AuthorID }).
+		AddFieldType("name", alacarte.Field(alacarte.Col("name"), alacarte.Ptr(func(t *Book) any { return &t.Name })).Hide())
+	authors := alacarte.New[Author]("authors").
+		AddSimpleField("id", func(t *Author) any { return &t.ID }).
+		AddSimpleField("name", func(t *Author) any { return &t.Name }).
+		AddFieldType("tags", alacarte.Field(alacarte.Col("tags"), func(t *Author) (alacarte.Ptrs, alacarte.Action) {
+			var tags string
+			return alacarte.Ptrs{&tags}, func() { t.Tags = strings.Split(tags, ",") }
+		})).
+		AddRelation("books", alacarte.HasManyByKey(books,
+			func(author Author) uint64 { return author.ID },
+			func(book Book) uint64 { return book.AuthorID },
+			func(author *Author, books []Book) { author.Books = books },
+			alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "author_id")).
+		SetDefault("id", "name")
+	require.NoError(t, authors.Err())
+
+	t.Run("hidden fields", func(t *testing.T) {
+		assert.Equal(t, []string{"author_id", "id"}, books.Query().Selection().Paths())
+		assert.Equal(t, []string{"author_id", "id"}, books.Query("*").Selection().Paths())
+		assert.Equal(t, []string{"id", "name"}, books.Query("id", "name").Selection().Paths())
+	})
+
+	t.Run("default selection", func(t *testing.T) {
+		assert.Equal(t, []string{"id", "name"}, authors.Query().Selection().Paths())
+		assert.Equal(t, []string{"id", "name"}, authors.Query("*").Selection().Paths())
+		assert.Equal(t, []string{"id", "name"}, authors.Query().Select().Selection().Paths())
+		assert.Equal(t, []string{"id"}, authors.Query("-name", "*").Selection().Paths())
+		assert.Equal(t, []string{"id", "name", "tags"}, authors.Query("*", "tags").Selection().Paths())
+	})
+
+	t.Run("relation expansion", func(t *testing.T) {
+		query := authors.Query("tags", "books")
+		found, err := query.Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"tags", "books", "books.author_id", "books.id"}, query.Selection().Paths())
+		require.Len(t, found, 1)
+		assert.Equal(t, []string{"a", "b"}, found[0].Tags)
+		assert.Empty(t, found[0].Name)
+		assert.Equal(t, []Book{{ID: 1, AuthorID: 1}}, found[0].Books)
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		invalid := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddPreset("all", "*").
+			SetDefault("id", "@all", "age")
+
+		assert.ErrorIs(t, invalid.Err(), alacarte.ErrInvalidArgument)
+		assert.ErrorContains(t, invalid.Err(), "default selection: field does not exist: age")
+		assert.Equal(t, []string{"id"}, invalid.Default)
+	})
+
+	t.Run("default leading back to itself", func(t *testing.T) {
+		writers := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddSimpleField("name", func(t *Author) any { return &t.Name })
+		works := alacarte.New[Book]("books").
+			AddSimpleField("id", func(t *Book) any { return &t.ID }).
+			AddSimpleField("author_id", func(t *Book) any { return &t.AuthorID }).
+			AddRelation("author", alacarte.BelongsTo(writers,
+				"author_id", func(b Book) uint64 { return b.AuthorID },
+				"id", func(a Author) uint64 { return a.ID },
+				func(b *Book, a Author) { b.Author = &a },
+			)).
+			SetDefault("id", "author")
+		writers.AddRelation("books", alacarte.HasManyByKey(works,
+			func(author Author) uint64 { return author.ID },
+			func(book Book) uint64 { return book.AuthorID },
+			func(author *Author, books []Book) { author.Books = books },
+			alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+			alacarte.DependsOn(),
+		).Keys("id", "author_id"))
+
+		writers.SetDefault("name", "books.author.name", "books")
+
+		assert.ErrorIs(t, writers.Err(), alacarte.ErrInvalidArgument)
+		assert.ErrorContains(t, writers.Err(), "default selection can not contain books")
+		assert.Equal(t, []string{"name", "books.author.name"}, writers.Default)
+		assert.Equal(t, []string{"name", "books", "books.author", "books.author.name"},
+			writers.Query().Selection().Paths())
+	})
+}
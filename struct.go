@@ -0,0 +1,84 @@
+package alacarte
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"pollex.nl/alacarte/internal/naming"
+)
+
+// FromStruct creates a schema from the `db` tags of the struct fields of T. The tag holds the column name, which is
+// also the field name, followed by options:
+//
+//	ID       uint64 `db:"id"`
+//	Name     string `db:"name,sortable,filterable"`
+//	Password string `db:"password,hidden"`
+//	Notes    string `db:"-"`
+//
+// Fields without options are sortable and filterable, like AddSimpleField. When sortable or filterable are given only
+// the listed capabilities are enabled. Hidden fields are only selected when named, see FieldType.Hide. Fields of
+// embedded structs are included. The result can be extended with AddField and AddRelation.
+func FromStruct[T any](table string) *ModelSchema[T] {
+	schema := New[T](table)
+
+	structType := reflect.TypeFor[T]()
+	if structType.Kind() != reflect.Struct {
+		schema.errors = append(schema.errors, fmt.Errorf("%w: %s is not a struct", ErrInvalidTag, structType))
+		return schema
+	}
+
+	schema.addStructFields(structType, nil)
+
+	return schema
+}
+
+func (schema *ModelSchema[T]) addStructFields(structType reflect.Type, index []int) {
+	for _, structField := range reflect.VisibleFields(structType) {
+		if len(structField.Index) > 1 {
+			// Promoted fields are added when recursing into the embedded struct
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), structField.Index...)
+
+		tag, tagged := structField.Tag.Lookup("db")
+		if !tagged && structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			schema.addStructFields(structField.Type, fieldIndex)
+			continue
+		}
+		if !tagged || tag == "-" || !structField.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = naming.SnakeCase(structField.Name)
+		}
+
+		field := Field(Col(name), Ptr(func(t *T) any {
+			return reflect.ValueOf(t).Elem().FieldByIndex(fieldIndex).Addr().Interface()
+		}))
+
+		if options != "" {
+			var capabilities []string
+			for _, option := range strings.Split(options, ",") {
+				switch option = strings.TrimSpace(option); option {
+				case "sortable", "filterable":
+					capabilities = append(capabilities, option)
+				case "hidden":
+					field.Hidden = true
+				default:
+					schema.errors = append(schema.errors,
+						fmt.Errorf("%w: option %q on %s", ErrInvalidTag, option, structField.Name))
+				}
+			}
+			if len(capabilities) > 0 {
+				field.Unsortable = !slices.Contains(capabilities, "sortable")
+				field.Unfilterable = !slices.Contains(capabilities, "filterable")
+			}
+		}
+
+		schema.AddFieldType(name, field)
+	}
+}
@@ -0,0 +1,108 @@
+//nolint:errcheck
+package alacarte_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+type Timestamps struct {
+	CreatedAt string `db:"created_at"`
+}
+
+type TaggedBook struct {
+	Timestamps
+	ID       uint64 `db:"id"`
+	Title    string `db:"name,sortable"`
+	AuthorID uint64 `db:",filterable"`
+	Notes    string `db:"-"`
+	Comments []Comment
+}
+
+func TestFromStruct(t *testing.T) {
+	schema := alacarte.FromStruct[TaggedBook]("books").
+		AddRelation("comments",
+			alacarte.HasManyByKey(comment,
+				func(book TaggedBook) uint64 { return book.ID },
+				func(comment Comment) uint64 { return comment.BookID },
+				func(book *TaggedBook, comments []Comment) { book.Comments = comments },
+				alacarte.WhereIDs("book_id", func(book TaggedBook) uint64 { return book.ID }),
+				alacarte.DependsOn(),
+			).Keys("id", "book_id"),
+		)
+	require.NoError(t, schema.Err())
+
+	t.Run("fields from tags", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"created_at", "id", "name", "author_id"}, keys(schema.Fields))
+		assert.False(t, schema.Fields["id"].Unsortable)
+		assert.False(t, schema.Fields["name"].Unsortable)
+		assert.True(t, schema.Fields["name"].Unfilterable)
+		assert.True(t, schema.Fields["author_id"].Unsortable)
+		assert.False(t, schema.Fields["author_id"].Unfilterable)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		db, sq := setupDB(t)
+		_, err := db.Exec("alter table books add column created_at text not null default '2024-01-01'")
+		require.NoError(t, err)
+		sq.Insert("books").Columns("id", "name", "author_id").
+			Values(1, "Life of Jeff", 1).
+			Values(2, "Cooking like Jeff", 2).Exec()
+		sq.Insert("book_comments").Values(1, "Great book!", 1).Exec()
+
+		books, err := schema.Query("*", "comments.name").
+			Where(alacarte.Eq("author_id", 1)).
+			OrderBy("name").
+			Collect(context.Background(), db)
+		require.NoError(t, err)
+
+		require.Len(t, books, 1)
+		assert.Equal(t, TaggedBook{
+			Timestamps: Timestamps{CreatedAt: "2024-01-01"},
+			ID:         1,
+			Title:      "Life of Jeff",
+			AuthorID:   1,
+			Comments:   []Comment{{Name: "Great book!", BookID: 1}},
+		}, books[0])
+	})
+
+	t.Run("options are enforced", func(t *testing.T) {
+		assert.ErrorIs(t, schema.Query("id").OrderBy("author_id").Err(), alacarte.ErrNotSortable)
+		assert.ErrorIs(t, schema.Query("id").Where(alacarte.Eq("name", "x")).Err(), alacarte.ErrNotFilterable)
+	})
+
+	t.Run("hidden option", func(t *testing.T) {
+		type User struct {
+			ID       uint64 `db:"id"`
+			Password string `db:"password,hidden"`
+			Token    string `db:"token,hidden,filterable"`
+		}
+		users := alacarte.FromStruct[User]("users")
+		require.NoError(t, users.Err())
+
+		assert.True(t, users.Fields["password"].Hidden)
+		assert.False(t, users.Fields["password"].Unsortable)
+		assert.True(t, users.Fields["token"].Unsortable)
+		assert.False(t, users.Fields["token"].Unfilterable)
+		assert.Equal(t, []string{"id"}, users.Query().Selection().Paths())
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		type Invalid struct {
+			ID uint64 `db:"id,primary"`
+		}
+		assert.ErrorIs(t, alacarte.FromStruct[Invalid]("invalid").Err(), alacarte.ErrInvalidTag)
+	})
+}
+
+func keys[V any](m map[string]V) []string {
+	result := []string{}
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package alacarte
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/samber/lo"
+)
+
+// FieldError is a field of a schema that can not be queried, as reported by Validate.
+type FieldError struct {
+	// Path is the field path from the validated schema, e.g. "books.genre.name". The path of a relation is reported
+	// when its table can not be queried.
+	Path  string
+	Table string
+	Err   error
+}
+
+func (err FieldError) Error() string {
+	return fmt.Sprintf("%s (table %s): %s", err.Path, err.Table, err.Err)
+}
+
+func (err FieldError) Unwrap() error {
+	return err.Err
+}
+
+// ValidationError holds all fields that failed validation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	lines := lo.Map(err.Fields, func(field FieldError, _ int) string { return field.Error() })
+
+	return "schema validation failed:\n" + strings.Join(lines, "\n")
+}
+
+func (err *ValidationError) Unwrap() []error {
+	return lo.Map(err.Fields, func(field FieldError, _ int) error { return field })
+}
+
+// validated holds the schemas that are already validated, so relations referring back to them are skipped.
+type validated map[any]bool
+
+// Validate checks the schema against the database. Every field, and every field of the related schemas, is selected
+// in a query that returns no rows, the fields that fail are returned in a *ValidationError. Construction errors of the
+// schema (see Err) are returned as is. Run it at start-up or in a test to catch renamed columns and tables.
+func (schema *ModelSchema[T]) Validate(ctx context.Context, db squirrel.BaseRunner) error {
+	if err := schema.Err(); err != nil {
+		return err
+	}
+
+	fields := schema.validate(ctx, db, "", validated{})
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+func (schema *ModelSchema[T]) validate(ctx context.Context, db squirrel.BaseRunner, path string, seen validated) []FieldError {
+	if seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+
+	base := schema.Query().baseQuery(db).Column("1").Limit(0)
+
+	// Fields can not be checked if the table itself fails
+	if err := validateQuery(ctx, base); err != nil {
+		return []FieldError{{Path: path, Table: schema.Table, Err: err}}
+	}
+
+	var errs []FieldError
+	for _, name := range sortedKeys(schema.Fields) {
+		if err := validateQuery(ctx, schema.Fields[name].Mod(base, schema.Table)); err != nil {
+			errs = append(errs, FieldError{Path: joinPath(path, name), Table: schema.Table, Err: err})
+		}
+	}
+
+	for _, name := range sortedKeys(schema.Relations) {
+		if validate := schema.Relations[name].Validate; validate != nil {
+			errs = append(errs, validate(ctx, db, joinPath(path, name), seen)...)
+		}
+	}
+
+	return errs
+}
+
+// validateQuery runs the query, which should not return any rows.
+func validateQuery(ctx context.Context, q Q) error {
+	rows, err := q.QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		slog.Default().Error("Validate: failed to close rows", "error", err.Error())
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := lo.Keys(m)
+	slices.Sort(keys)
+
+	return keys
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,106 @@
+package alacarte_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pollex.nl/alacarte"
+)
+
+func TestValidate(t *testing.T) {
+	db, _ := setupDB(t)
+	ctx := context.Background()
+
+	t.Run("valid schemas", func(t *testing.T) {
+		assert.NoError(t, author.Validate(ctx, db))
+		assert.NoError(t, comment.Validate(ctx, db))
+	})
+
+	t.Run("reports every field path", func(t *testing.T) {
+		brokenGenre := alacarte.New[Genre]("genres").
+			AddSimpleField("id", func(t *Genre) any { return &t.ID }).
+			AddField("name", alacarte.Col("title"), alacarte.Ptr(func(t *Genre) any { return &t.Name }))
+
+		brokenBook := alacarte.New[Book]("books").
+			AddSimpleField("id", func(t *Book) any { return &t.ID }).
+			AddSimpleField("author", func(t *Book) any { return &t.AuthorID }).
+			AddRelation("genres",
+				alacarte.ManyToMany(brokenGenre,
+					alacarte.JoinTable{Table: "book_genres", ParentKey: "book_id", ChildKey: "genre_id"},
+					func(book Book) uint64 { return book.ID },
+					func(book *Book, genres []Genre) { book.Genres = genres },
+					alacarte.DependsOn("id"),
+				),
+			).
+			AddRelation("comments",
+				alacarte.HasMany(alacarte.New[Comment]("comments"),
+					func(book Book, comment Comment) bool { return comment.BookID == book.ID },
+					func(book *Book, comments []Comment) { book.Comments = comments },
+					alacarte.WhereIDs("book_id", func(book Book) uint64 { return book.ID }),
+					alacarte.DependsOn("id"),
+				),
+			)
+
+		brokenAuthor := alacarte.New[Author]("authors").
+			AddSimpleField("id", func(t *Author) any { return &t.ID }).
+			AddRelation("books",
+				alacarte.HasManyByKey(brokenBook,
+					func(author Author) uint64 { return author.ID },
+					func(book Book) uint64 { return book.AuthorID },
+					func(author *Author, books []Book) { author.Books = books },
+					alacarte.WhereIDs("author_id", func(a Author) uint64 { return a.ID }),
+					alacarte.DependsOn(),
+				).Keys("id", "author"),
+			)
+
+		err := brokenAuthor.Validate(ctx, db)
+		require.Error(t, err)
+
+		var validationErr *alacarte.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+
+		paths := map[string]string{}
+		for _, field := range validationErr.Fields {
+			paths[field.Path] = field.Table
+		}
+		assert.Equal(t, map[string]string{
+			"books.author":      "books",
+			"books.comments":    "comments",
+			"books.genres.name": "genres",
+		}, paths)
+	})
+
+	t.Run("join table", func(t *testing.T) {
+		schema := alacarte.New[Book]("books").
+			AddSimpleField("id", func(t *Book) any { return &t.ID }).
+			AddRelation("genres",
+				alacarte.ManyToMany(genre,
+					alacarte.JoinTable{Table: "book_genres", ParentKey: "book", ChildKey: "genre_id"},
+					func(book Book) uint64 { return book.ID },
+					func(book *Book, genres []Genre) { book.Genres = genres },
+					alacarte.DependsOn("id"),
+				),
+			)
+
+		var validationErr *alacarte.ValidationError
+		require.ErrorAs(t, schema.Validate(ctx, db), &validationErr)
+		require.Len(t, validationErr.Fields, 1)
+		assert.Equal(t, "genres", validationErr.Fields[0].Path)
+		assert.Equal(t, "book_genres", validationErr.Fields[0].Table)
+	})
+
+	t.Run("construction errors", func(t *testing.T) {
+		schema := alacarte.New[Book]("books").
+			AddRelation("genres", alacarte.HasMany(genre,
+				func(Book, Genre) bool { return true },
+				func(*Book, []Genre) {},
+				alacarte.WhereIDs("id", func(book Book) uint64 { return book.ID }),
+				alacarte.DependsOn(),
+			).Keys("id", "book_id"))
+
+		assert.ErrorIs(t, schema.Validate(ctx, db), alacarte.ErrNoSuchField)
+	})
+}